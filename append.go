@@ -0,0 +1,78 @@
+package interband
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SeqKeyWidth is the zero-padded width of keys allocated by Append, so they
+// sort lexically in sequence order up to 9,999,999 entries.
+const SeqKeyWidth = 7
+
+// Append writes a message under the next sequence key of the channel, such as
+// "0000042", and returns the key. Allocation happens under the channel lock,
+// so concurrent appenders get distinct, increasing keys. The last allocated
+// sequence is kept in the channel's reserved .interband-seq file, and the
+// channel is also scanned for numeric keys, so sequences keep increasing
+// after restarts and after pruning empties the channel.
+func Append(namespace, channel, typ, sessionID string, payload map[string]any) (string, error) {
+	return envClient().Append(namespace, channel, typ, sessionID, payload)
+}
+
+func (c *Client) Append(namespace, channel, typ, sessionID string, payload map[string]any) (string, error) {
+	if err := c.writable(); err != nil {
+		return "", err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return "", err
+	}
+	var key string
+	err = c.WithChannelLock(namespace, channel, func() error {
+		next, err := c.nextSeq(dir)
+		if err != nil {
+			return err
+		}
+		key = fmt.Sprintf("%0*d", SeqKeyWidth, next)
+		p, err := c.Path(namespace, channel, key)
+		if err != nil {
+			return err
+		}
+		if err := c.Write(p, namespace, typ, sessionID, payload); err != nil {
+			return err
+		}
+		return c.backend().WriteAtomic(context.Background(), seqPath(dir), []byte(strconv.FormatUint(next, 10)))
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// nextSeq returns one past the highest sequence recorded in dir's seq file or
+// used as a key. The channel lock must be held.
+func (c *Client) nextSeq(dir string) (uint64, error) {
+	var last uint64
+	if data, err := c.backend().ReadFile(seqPath(dir)); err == nil {
+		if v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			last = v
+		}
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if v, err := strconv.ParseUint(entry.key, 10, 64); err == nil && v > last {
+			last = v
+		}
+	}
+	return last + 1, nil
+}
+
+func seqPath(dir string) string {
+	return filepath.Join(dir, ReservedPrefix+"-seq")
+}
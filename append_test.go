@@ -0,0 +1,53 @@
+package interband
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestAppendAllocatesIncreasingKeys(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	const writers = 10
+	var wg sync.WaitGroup
+	keys := make(chan string, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := Append("custom", "log", "anything", "s", map[string]any{})
+			if err != nil {
+				t.Errorf("append failed: %v", err)
+			}
+			keys <- key
+		}()
+	}
+	wg.Wait()
+	close(keys)
+
+	var got []string
+	for key := range keys {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	for idx, key := range got {
+		if want := fmt.Sprintf("%07d", idx+1); key != want {
+			t.Fatalf("expected %s, got %s", want, key)
+		}
+	}
+
+	// Emptying the channel does not reset the sequence.
+	if n, err := DeleteMatching("custom", "log", "*"); err != nil || n != writers {
+		t.Fatalf("delete failed: %d %v", n, err)
+	}
+	key, err := Append("custom", "log", "anything", "s", map[string]any{})
+	if err != nil || key != "0000011" {
+		t.Fatalf("expected 0000011 after emptying, got %s %v", key, err)
+	}
+	envs, _, _ := ListChannel("custom", "log")
+	if len(envs) != 1 {
+		t.Fatalf("seq file should not be listed, got %d envelopes", len(envs))
+	}
+}
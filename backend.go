@@ -0,0 +1,383 @@
+package interband
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend is the storage a Client reads and writes. Paths are the same
+// filesystem-style paths Path and ChannelDir return; directories exist
+// implicitly once a file is written below them.
+type Backend interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	// WriteAtomic replaces name with data so readers never see a partial
+	// file, creating parent directories as needed.
+	WriteAtomic(ctx context.Context, name string, data []byte) error
+	Remove(name string) error
+}
+
+// Locker is implemented by backends that can serialize access to a channel
+// directory. WithChannelLock runs fn unlocked on backends without it.
+type Locker interface {
+	Lock(dir string) (unlock func(), err error)
+}
+
+// ExclusiveCreator is implemented by backends that can create a file only if
+// it does not exist yet, atomically with respect to other creators.
+// WriteIfAbsent requires it.
+type ExclusiveCreator interface {
+	// CreateExclusive writes name like WriteAtomic but fails with an error
+	// matching fs.ErrExist if name already exists.
+	CreateExclusive(ctx context.Context, name string, data []byte) error
+}
+
+// Renamer is implemented by backends that can move a file atomically, keeping
+// its contents and modification time. MoveKey copies and removes on backends
+// without it.
+type Renamer interface {
+	// Rename moves oldname to newname, replacing newname and creating its
+	// parent directories as needed.
+	Rename(oldname, newname string) error
+}
+
+// Appender is implemented by backends that can append to a file in place.
+// LogWriter requires it.
+type Appender interface {
+	// OpenAppend opens name for appending, creating it and its parent
+	// directories as needed. Each Write on the result lands whole at the end
+	// of the file.
+	OpenAppend(name string) (io.WriteCloser, error)
+}
+
+// OSBackend stores envelopes on the local filesystem. It is the default.
+type OSBackend struct {
+	// FileMode and DirMode are applied to written envelopes and created
+	// directories. Zero leaves envelopes 0600 and directories 0755 less the
+	// umask.
+	FileMode os.FileMode
+	DirMode  os.FileMode
+}
+
+func (OSBackend) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (OSBackend) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (OSBackend) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (OSBackend) Remove(name string) error                   { return os.Remove(name) }
+
+func (b OSBackend) WriteAtomic(ctx context.Context, name string, data []byte) error {
+	return writeFileAtomic(ctx, name, data, b.FileMode, b.DirMode)
+}
+
+func (b OSBackend) CreateExclusive(ctx context.Context, name string, data []byte) error {
+	return createFileExclusive(ctx, name, data, b.FileMode, b.DirMode)
+}
+
+// Rename fails with an error matching syscall.EXDEV when the two names are on
+// different filesystems.
+func (b OSBackend) Rename(oldname, newname string) error {
+	dir := filepath.Dir(newname)
+	if err := mkdirAllMode(dir, b.DirMode); err != nil {
+		return err
+	}
+	if err := os.Rename(oldname, newname); err != nil {
+		return err
+	}
+	if err := syncDir(dir); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(oldname))
+}
+
+// OpenAppend opens name with O_APPEND, so concurrent appenders in other
+// processes do not overwrite each other's lines.
+func (b OSBackend) OpenAppend(name string) (io.WriteCloser, error) {
+	if err := mkdirAllMode(filepath.Dir(name), b.DirMode); err != nil {
+		return nil, err
+	}
+	mode := b.FileMode
+	if mode == 0 {
+		mode = 0o600
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode)
+	if err != nil {
+		return nil, err
+	}
+	if b.FileMode != 0 {
+		if err := f.Chmod(b.FileMode); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Lock takes an exclusive advisory lock on dir's .interband-lock file, shared
+// across processes (flock on Unix, LockFileEx on Windows).
+func (b OSBackend) Lock(dir string) (func(), error) {
+	if err := mkdirAllMode(dir, b.DirMode); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, ".interband-lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// MemBackend keeps envelopes in memory, for tests and short-lived stores that
+// should never touch disk. The zero value is ready to use and safe for
+// concurrent use; it is not shared across processes.
+type MemBackend struct {
+	// Clock stamps the modification time of written files. Nil means the
+	// system clock.
+	Clock Clock
+
+	mu    sync.Mutex
+	files map[string]memFile
+	locks map[string]*sync.Mutex
+}
+
+type memFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemBackend returns an empty in-memory backend.
+func NewMemBackend() *MemBackend {
+	return &MemBackend{}
+}
+
+func (m *MemBackend) Stat(name string) (fs.FileInfo, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.files[name]; ok {
+		return memInfo{name: filepath.Base(name), size: int64(len(f.data)), modTime: f.modTime}, nil
+	}
+	if m.hasChildren(name) {
+		return memInfo{name: filepath.Base(name), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *MemBackend) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.hasChildren(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	seen := make(map[string]bool)
+	var out []fs.DirEntry
+	prefix := name + string(filepath.Separator)
+	for p, f := range m.files {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok {
+			continue
+		}
+		child, _, nested := strings.Cut(rest, string(filepath.Separator))
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		info := memInfo{name: child, dir: nested}
+		if !nested {
+			info.size = int64(len(f.data))
+			info.modTime = f.modTime
+		}
+		out = append(out, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+func (m *MemBackend) ReadFile(name string) ([]byte, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), f.data...), nil
+}
+
+func (m *MemBackend) WriteAtomic(ctx context.Context, name string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string]memFile)
+	}
+	modTime := time.Now()
+	if m.Clock != nil {
+		modTime = m.Clock.Now()
+	}
+	m.files[name] = memFile{data: append([]byte(nil), data...), modTime: modTime}
+	return nil
+}
+
+func (m *MemBackend) CreateExclusive(ctx context.Context, name string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; ok {
+		return &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	if m.files == nil {
+		m.files = make(map[string]memFile)
+	}
+	modTime := time.Now()
+	if m.Clock != nil {
+		modTime = m.Clock.Now()
+	}
+	m.files[name] = memFile{data: append([]byte(nil), data...), modTime: modTime}
+	return nil
+}
+
+func (m *MemBackend) Remove(name string) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *MemBackend) Rename(oldname, newname string) error {
+	oldname, newname = filepath.Clean(oldname), filepath.Clean(newname)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[oldname]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	delete(m.files, oldname)
+	m.files[newname] = f
+	return nil
+}
+
+func (m *MemBackend) OpenAppend(name string) (io.WriteCloser, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		if m.files == nil {
+			m.files = make(map[string]memFile)
+		}
+		m.files[name] = memFile{modTime: m.stamp()}
+	}
+	return memAppender{m: m, name: name}, nil
+}
+
+type memAppender struct {
+	m    *MemBackend
+	name string
+}
+
+func (a memAppender) Write(p []byte) (int, error) {
+	a.m.mu.Lock()
+	defer a.m.mu.Unlock()
+	f, ok := a.m.files[a.name]
+	if !ok {
+		return 0, &fs.PathError{Op: "write", Path: a.name, Err: fs.ErrNotExist}
+	}
+	a.m.files[a.name] = memFile{data: append(f.data, p...), modTime: a.m.stamp()}
+	return len(p), nil
+}
+
+func (memAppender) Close() error { return nil }
+
+// stamp returns the modification time for a write. m.mu must be held.
+func (m *MemBackend) stamp() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+	return time.Now()
+}
+
+// Chtimes sets the modification time of a stored file, letting tests control
+// retention and max-files ordering exactly.
+func (m *MemBackend) Chtimes(name string, modTime time.Time) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	f.modTime = modTime
+	m.files[name] = f
+	return nil
+}
+
+// Lock serializes callers within this process on dir.
+func (m *MemBackend) Lock(dir string) (func(), error) {
+	dir = filepath.Clean(dir)
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := m.locks[dir]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[dir] = l
+	}
+	m.mu.Unlock()
+	l.Lock()
+	return l.Unlock, nil
+}
+
+// hasChildren reports whether any file lives below dir. m.mu must be held.
+func (m *MemBackend) hasChildren(dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	for p := range m.files {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
@@ -0,0 +1,73 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newMemClient(t *testing.T) (*Client, *MemBackend) {
+	t.Helper()
+	mem := NewMemBackend()
+	cfg := DefaultConfig()
+	cfg.Root = "/mem"
+	cfg.PruneInterval = 0
+	cfg.Backend = mem
+	return NewClient(cfg), mem
+}
+
+func TestMemBackendWriteReadList(t *testing.T) {
+	c, _ := newMemClient(t)
+	p, err := c.Path("custom", "events", "k1")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := c.Write(p, "custom", "anything", "s", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	payload, err := c.ReadPayload(p)
+	if err != nil || payload["n"] != float64(1) {
+		t.Fatalf("read failed: %v %v", payload, err)
+	}
+	if ok, err := c.Exists("custom", "events", "k1"); !ok || err != nil {
+		t.Fatalf("expected key to exist: %v %v", ok, err)
+	}
+	envs, _, err := c.ListChannel("custom", "events")
+	if err != nil || len(envs) != 1 {
+		t.Fatalf("unexpected list: %v %v", envs, err)
+	}
+	if _, err := c.ReadEnvelope(p + "-missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemBackendPruneMaxFilesIsDeterministic(t *testing.T) {
+	c, mem := newMemClient(t)
+	ref := ChannelRef{Namespace: "custom", Channel: "events"}
+	c.cfg.MaxFiles = map[ChannelRef]int{ref: 2}
+	c.cfg.RetentionSeconds = map[ChannelRef]int{ref: 3600}
+
+	base := time.Now().Add(-time.Minute)
+	for idx, key := range []string{"a", "b", "c", "d"} {
+		p, _ := c.Path("custom", "events", key)
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		if err := mem.Chtimes(p, base.Add(time.Duration(idx)*time.Second)); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	stats, err := c.PruneChannelStats("custom", "events")
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if stats.Overflow != 2 || stats.Remaining != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	for key, want := range map[string]bool{"a": false, "b": false, "c": true, "d": true} {
+		if ok, _ := c.Exists("custom", "events", key); ok != want {
+			t.Fatalf("key %s exists=%v, want %v", key, ok, want)
+		}
+	}
+}
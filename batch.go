@@ -0,0 +1,177 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BatchItem is one message for WriteBatch.
+type BatchItem struct {
+	Key       string
+	Type      string
+	SessionID string
+	Payload   map[string]any
+}
+
+// BatchOption configures WriteBatch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	allOrNothing bool
+}
+
+// AllOrNothing makes WriteBatch undo the items it already wrote when a write
+// fails, restoring each one's previous message or removing it if there was
+// none. The batch runs under the channel lock, but readers that do not take
+// the lock may still see it half-written before the rollback.
+func AllOrNothing() BatchOption {
+	return func(o *batchOptions) { o.allOrNothing = true }
+}
+
+// WriteBatch writes several messages into one channel, resolving and creating
+// the channel directory once. Validation is all-or-nothing: every item is
+// checked before anything is written, including that no two keys map to the
+// same file, and if any fails the batch is rejected with nothing written, the
+// per-item errors set, and the returned error joining them. Writes are then
+// best-effort by default: each item is written atomically on its own, a
+// failure is recorded in its slot, and the rest are still attempted, so the
+// returned error is only set for invalid arguments or a rejected batch. With
+// AllOrNothing the first failed write stops the batch, is recorded in its
+// slot, and is returned after the earlier items are rolled back.
+func WriteBatch(namespace, channel string, items []BatchItem, opts ...BatchOption) ([]error, error) {
+	return envClient().WriteBatch(namespace, channel, items, opts...)
+}
+
+func (c *Client) WriteBatch(namespace, channel string, items []BatchItem, opts ...BatchOption) ([]error, error) {
+	if err := c.writable(); err != nil {
+		return nil, err
+	}
+	var o batchOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(items))
+	entries := make([]batchEntry, len(items))
+	byPath := make(map[string]int, len(items))
+	var invalid []error
+	for idx, item := range items {
+		entry, err := c.prepareBatchItem(dir, namespace, item)
+		if err == nil {
+			if prev, dup := byPath[entry.path]; dup {
+				err = c.observeValidation(invalidEnvelope("key", "key %q is stored in the same file as item %d", item.Key, prev))
+			} else {
+				byPath[entry.path] = idx
+			}
+		}
+		if err != nil {
+			errs[idx] = err
+			invalid = append(invalid, err)
+			continue
+		}
+		entries[idx] = entry
+	}
+	if len(invalid) > 0 {
+		return errs, errors.Join(invalid...)
+	}
+
+	if !o.allOrNothing {
+		ready := make(map[string]struct{})
+		for idx, entry := range entries {
+			errs[idx] = c.publishBatchItem(ready, entry)
+		}
+		return errs, nil
+	}
+	err = c.WithChannelLock(namespace, channel, func() error {
+		return c.writeBatchAllOrNothing(entries, errs)
+	})
+	return errs, err
+}
+
+// batchEntry is a batch item resolved, stamped, and encoded for writing.
+type batchEntry struct {
+	path string
+	env  Envelope
+	data []byte
+}
+
+func (c *Client) prepareBatchItem(dir, namespace string, item BatchItem) (batchEntry, error) {
+	if strings.TrimSpace(item.Key) == "" {
+		return batchEntry{}, errors.New("key is required")
+	}
+	p, err := c.keyPath(dir, item.Key)
+	if err != nil {
+		return batchEntry{}, err
+	}
+	env, err := c.newEnvelope(p, namespace, item.Type, item.SessionID, item.Payload)
+	if err != nil {
+		return batchEntry{}, err
+	}
+	env.Key = item.Key
+	data, err := encodeEnvelope(env)
+	if err != nil {
+		return batchEntry{}, err
+	}
+	if err := checkSize(p, int64(len(data)), c.cfg.MaxPayloadBytes); err != nil {
+		return batchEntry{}, err
+	}
+	return batchEntry{path: p, env: env, data: data}, nil
+}
+
+func (c *Client) publishBatchItem(ready map[string]struct{}, entry batchEntry) error {
+	if err := c.checkPhaseTransition(entry.path, entry.env); err != nil {
+		return c.observeValidation(err)
+	}
+	if err := c.publishReady(ready, entry.path, entry.data); err != nil {
+		return err
+	}
+	c.observe(func(o Observer) { o.OnWrite(entry.env.Namespace, entry.env.Type, len(entry.data)) })
+	return nil
+}
+
+// writeBatchAllOrNothing writes entries in order, restoring the files already
+// written when one fails. The channel lock must be held.
+func (c *Client) writeBatchAllOrNothing(entries []batchEntry, errs []error) error {
+	ready := make(map[string]struct{})
+	previous := make([][]byte, len(entries))
+	existed := make([]bool, len(entries))
+	for idx, entry := range entries {
+		prev, err := c.backend().ReadFile(entry.path)
+		switch {
+		case err == nil:
+			previous[idx], existed[idx] = prev, true
+			errs[idx] = c.publishBatchItem(ready, entry)
+		case errors.Is(err, os.ErrNotExist):
+			errs[idx] = c.publishBatchItem(ready, entry)
+		default:
+			errs[idx] = err
+		}
+		if errs[idx] == nil {
+			continue
+		}
+		rollback := []error{fmt.Errorf("batch item %d: %w", idx, errs[idx])}
+		for back := idx - 1; back >= 0; back-- {
+			p := entries[back].path
+			var err error
+			if existed[back] {
+				err = c.backend().WriteAtomic(context.Background(), p, previous[back])
+			} else {
+				err = c.backend().Remove(p)
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				rollback = append(rollback, fmt.Errorf("rolling back %s: %w", p, err))
+			}
+		}
+		return errors.Join(rollback...)
+	}
+	return nil
+}
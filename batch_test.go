@@ -0,0 +1,92 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWriteBatch(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	items := []BatchItem{
+		{Key: "a", Type: "anything", SessionID: "s", Payload: map[string]any{"n": 1}},
+		{Key: "b", Type: "anything", SessionID: "s", Payload: map[string]any{"n": 2}},
+	}
+	errs, err := WriteBatch("custom", "events", items)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	for idx, e := range errs {
+		if e != nil {
+			t.Fatalf("item %d failed: %v", idx, e)
+		}
+	}
+	envs, _, err := ListChannel("custom", "events")
+	if err != nil || len(envs) != 2 {
+		t.Fatalf("expected 2 envelopes, got %d (%v)", len(envs), err)
+	}
+}
+
+func TestWriteBatchRejectsInvalidBatch(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	items := []BatchItem{
+		{Key: "ok", Type: "anything", SessionID: "s", Payload: map[string]any{}},
+		{Key: "bad", Type: "bead_phase", SessionID: "s", Payload: map[string]any{"id": "x"}},
+	}
+	errs, err := WriteBatch("interphase", "bead", items)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if errs[0] != nil || !errors.Is(errs[1], ErrValidation) {
+		t.Fatalf("unexpected per-item errors: %v", errs)
+	}
+	if ok, _ := Exists("interphase", "bead", "ok"); ok {
+		t.Fatal("valid item should not be written when the batch is rejected")
+	}
+}
+
+func TestWriteBatchRejectsDuplicatePaths(t *testing.T) {
+	c, _ := newMemClient(t)
+	items := []BatchItem{
+		{Key: "a!", Type: "anything", SessionID: "s", Payload: map[string]any{"n": 1}},
+		{Key: "a?", Type: "anything", SessionID: "s", Payload: map[string]any{"n": 2}},
+	}
+	errs, err := c.WriteBatch("custom", "events", items)
+	if !errors.Is(err, ErrValidation) || errs[0] != nil || !errors.Is(errs[1], ErrValidation) {
+		t.Fatalf("expected the second key to be rejected, got %v (%v)", err, errs)
+	}
+	if ok, _ := c.Exists("custom", "events", "a!"); ok {
+		t.Fatal("nothing should be written when the batch is rejected")
+	}
+}
+
+func TestWriteBatchAllOrNothingRollsBack(t *testing.T) {
+	c, _ := newMemClient(t)
+	c.cfg.CheckTransitions = true
+	bead := func(id, phase string) map[string]any {
+		return map[string]any{"id": id, "phase": phase, "reason": "r", "ts": 1}
+	}
+	for key, phase := range map[string]string{"x": "executing", "b": "done"} {
+		if err := c.WriteKey("interphase", "bead", key, "bead_phase", "s", bead("iv-"+key, phase)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	items := []BatchItem{
+		{Key: "x", Type: "bead_phase", SessionID: "s", Payload: bead("iv-x", "done")},
+		{Key: "a", Type: "bead_phase", SessionID: "s", Payload: bead("iv-a", "brainstorm")},
+		{Key: "b", Type: "bead_phase", SessionID: "s", Payload: bead("iv-b", "planned")},
+	}
+	errs, err := c.WriteBatch("interphase", "bead", items, AllOrNothing())
+	if !errors.Is(err, ErrValidation) || errs[0] != nil || errs[1] != nil || errs[2] == nil {
+		t.Fatalf("expected the backwards transition to fail the batch, got %v (%v)", err, errs)
+	}
+	got, err := c.ReadChannelMap("interphase", "bead")
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(got) != 2 || got["x"].Payload["phase"] != "executing" || got["b"].Payload["phase"] != "done" {
+		t.Fatalf("batch was not rolled back: %v", got)
+	}
+}
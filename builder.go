@@ -0,0 +1,65 @@
+package interband
+
+import "time"
+
+// EnvelopeBuilder assembles an Envelope step by step. Create one with
+// NewEnvelope; the Envelope struct remains available for full control.
+type EnvelopeBuilder struct {
+	c         *Client
+	namespace string
+	typ       string
+	sessionID string
+	at        time.Time
+	payload   map[string]any
+}
+
+// NewEnvelope starts an envelope of the given namespace and type. Build stamps
+// it with ProtocolVersion and, unless At is called, the current time.
+func NewEnvelope(namespace, typ string) *EnvelopeBuilder {
+	return envClient().NewEnvelope(namespace, typ)
+}
+
+// NewEnvelope starts an envelope stamped with the client's protocol version,
+// clock, and timestamp precision.
+func (c *Client) NewEnvelope(namespace, typ string) *EnvelopeBuilder {
+	return &EnvelopeBuilder{c: c, namespace: namespace, typ: typ, payload: map[string]any{}}
+}
+
+// Session sets the session ID.
+func (b *EnvelopeBuilder) Session(id string) *EnvelopeBuilder {
+	b.sessionID = id
+	return b
+}
+
+// At sets the envelope timestamp.
+func (b *EnvelopeBuilder) At(t time.Time) *EnvelopeBuilder {
+	b.at = t
+	return b
+}
+
+// Set sets one payload field.
+func (b *EnvelopeBuilder) Set(key string, value any) *EnvelopeBuilder {
+	b.payload[key] = value
+	return b
+}
+
+// Build returns the envelope, failing if it would not pass ValidateEnvelope.
+// The builder may be reused; each Build copies the payload.
+func (b *EnvelopeBuilder) Build() (Envelope, error) {
+	at := b.at
+	if at.IsZero() {
+		at = b.c.now()
+	}
+	env := Envelope{
+		Version:   b.c.cfg.ProtocolVersion,
+		Namespace: b.namespace,
+		Type:      b.typ,
+		SessionID: b.c.sessionOrDefault(b.namespace, b.sessionID),
+		Timestamp: at.UTC().Format(timestampLayout(b.c.cfg.TimestampPrecision)),
+		Payload:   NormalizePayload(b.payload),
+	}
+	if err := ValidateEnvelope(env); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
@@ -0,0 +1,59 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeBuilder(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PROTOCOL_VERSION", "1.2.0")
+
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("x", 3600))
+	b := NewEnvelope("interphase", "bead_phase").
+		Session("s1").
+		At(at).
+		Set("id", "iv-1").
+		Set("phase", "executing").
+		Set("reason", "r").
+		Set("ts", 5)
+	env, err := b.Build()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if env.Version != "1.2.0" || env.SessionID != "s1" || env.Payload["ts"] != 5.0 {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+	if got, _ := env.Time(); !got.Equal(at) {
+		t.Fatalf("timestamp not applied: %s", env.Timestamp)
+	}
+
+	b.Set("id", "iv-2")
+	again, _ := b.Build()
+	if env.Payload["id"] != "iv-1" || again.Payload["id"] != "iv-2" {
+		t.Fatal("builds should not share payload maps")
+	}
+
+	p, _ := Path("interphase", "bead", "s1")
+	if err := WriteEnvelope(p, env); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	if _, err := NewEnvelope("interphase", "bead_phase").Set("id", "x").Build(); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error for incomplete payload, got %v", err)
+	}
+	if _, err := NewEnvelope("", "anything").Build(); err == nil {
+		t.Fatal("expected error for missing namespace")
+	}
+
+	clock := NewFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewClient(Config{Root: t.TempDir(), Clock: clock})
+	env, err = c.NewEnvelope("custom", "anything").Build()
+	if err != nil || env.Version != CurrentVersion {
+		t.Fatalf("client build failed: %+v %v", env, err)
+	}
+	if got, _ := env.Time(); !got.Equal(clock.Now()) {
+		t.Fatalf("client clock not used: %s", env.Timestamp)
+	}
+}
@@ -0,0 +1,115 @@
+package interband
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WriteBundle writes several envelopes into one file as a JSON array, for
+// archival channels where per-file overhead dominates. Every envelope is
+// validated as WriteEnvelope would before anything is written. Bundles are a
+// Go-only format: the channel readers, such as ListChannel, Query, and
+// iterators, expand them, but ReadEnvelope and the bash helpers treat them as
+// unreadable.
+func WriteBundle(targetPath string, envs []Envelope) error {
+	return envClient().WriteBundle(targetPath, envs)
+}
+
+func (c *Client) WriteBundle(targetPath string, envs []Envelope) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(targetPath) == "" {
+		return errors.New("target path is required")
+	}
+	if len(envs) == 0 {
+		return errors.New("bundle must contain at least one envelope")
+	}
+	for idx, env := range envs {
+		if err := validateEnvelope(env, c.writeOptions()); err != nil {
+			return fmt.Errorf("bundle item %d: %w", idx, err)
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(envs); err != nil {
+		return err
+	}
+	if err := checkSize(targetPath, int64(buf.Len()), c.cfg.MaxPayloadBytes); err != nil {
+		return err
+	}
+	return c.backend().WriteAtomic(context.Background(), targetPath, buf.Bytes())
+}
+
+// ReadBundle reads a file written by WriteBundle, validating each envelope as
+// ReadEnvelope would. A file holding a single envelope is returned as a
+// one-element bundle.
+func ReadBundle(sourcePath string) ([]Envelope, error) {
+	return envClient().ReadBundle(sourcePath)
+}
+
+func (c *Client) ReadBundle(sourcePath string) ([]Envelope, error) {
+	if strings.TrimSpace(sourcePath) == "" {
+		return nil, errors.New("source path is required")
+	}
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeEntry(data)
+}
+
+// readChannelFile reads a channel file for the channel readers: one envelope,
+// read as ReadEnvelope reads it, or every envelope of a bundle. Expired
+// envelopes are returned too; callers skip them with c.expired.
+func (c *Client) readChannelFile(sourcePath string) ([]Envelope, error) {
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	envs, err := c.decodeEntry(data)
+	if err != nil {
+		return nil, c.observeValidation(err)
+	}
+	for _, env := range envs {
+		c.observe(func(o Observer) { o.OnRead(env.Namespace, env.Type, len(data)) })
+	}
+	return envs, nil
+}
+
+// decodeEntry decodes a channel file that holds either one envelope or a
+// bundle, validating every envelope with the client's read options.
+func (c *Client) decodeEntry(data []byte) ([]Envelope, error) {
+	var envs []Envelope
+	if isBundle(data) {
+		if err := json.Unmarshal(data, &envs); err != nil {
+			return nil, malformed(err)
+		}
+	} else {
+		var env Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, malformed(err)
+		}
+		envs = []Envelope{env}
+	}
+	for idx, env := range envs {
+		if err := validateEnvelope(env, c.readOptions()); err != nil {
+			if len(envs) > 1 {
+				return nil, fmt.Errorf("bundle item %d: %w", idx, err)
+			}
+			return nil, err
+		}
+	}
+	return envs, nil
+}
+
+// isBundle reports whether data is a JSON array rather than an object.
+func isBundle(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
@@ -0,0 +1,106 @@
+package interband
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBundleRoundTripAndListExpansion(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mk := func(n int, at time.Time) Envelope {
+		return Envelope{Version: "1.0.0", Namespace: "custom", Type: "anything", Timestamp: at.Format(time.RFC3339), Payload: map[string]any{"n": float64(n)}}
+	}
+	envs := []Envelope{mk(1, base), mk(3, base.Add(2*time.Second)), mk(2, base)}
+
+	p, err := Path("custom", "archive", "bundle-1")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := WriteBundle(p, envs); err != nil {
+		t.Fatalf("write bundle failed: %v", err)
+	}
+	got, err := ReadBundle(p)
+	if err != nil || len(got) != 3 || got[1].Payload["n"] != float64(3) {
+		t.Fatalf("unexpected bundle: %v %v", got, err)
+	}
+	writeAt(t, "custom", "archive", "single", base.Add(time.Second), map[string]any{"n": 2.5})
+
+	listed, skipped, err := ListChannel("custom", "archive")
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("list failed: %v %v", err, skipped)
+	}
+	var order []any
+	for _, env := range listed {
+		order = append(order, env.Payload["n"])
+	}
+	want := []any{float64(1), float64(2), 2.5, float64(3)}
+	if len(order) != len(want) {
+		t.Fatalf("unexpected listing: %v", order)
+	}
+	for idx := range want {
+		if order[idx] != want[idx] {
+			t.Fatalf("unexpected listing order: %v", order)
+		}
+	}
+
+	single, _ := Path("custom", "archive", "single")
+	if one, err := ReadBundle(single); err != nil || len(one) != 1 {
+		t.Fatalf("single envelope should read as a one-element bundle: %v %v", one, err)
+	}
+
+	bad := []Envelope{mk(1, base), {Version: "1.0.0", Namespace: "custom", Timestamp: base.Format(time.RFC3339), Payload: map[string]any{}}}
+	if err := WriteBundle(p, bad); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestChannelReadersExpandBundles(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	mk := func(n int, at time.Time) Envelope {
+		return Envelope{Version: "1.0.0", Namespace: "custom", Type: "anything", Timestamp: at.Format(time.RFC3339), Payload: map[string]any{"n": float64(n)}}
+	}
+	p, _ := Path("custom", "archive", "bundle-1")
+	if err := WriteBundle(p, []Envelope{mk(1, base), mk(3, base.Add(2*time.Second))}); err != nil {
+		t.Fatalf("write bundle failed: %v", err)
+	}
+	writeAt(t, "custom", "archive", "single", base.Add(time.Second), map[string]any{"n": 2})
+	order := func(envs []Envelope) []any {
+		var out []any
+		for _, env := range envs {
+			out = append(out, env.Payload["n"])
+		}
+		return out
+	}
+
+	it, err := NewChannelIterator("custom", "archive")
+	if err != nil {
+		t.Fatalf("iterator failed: %v", err)
+	}
+	var iterated []Envelope
+	for it.Next() {
+		iterated = append(iterated, it.Envelope())
+	}
+	if it.Err() != nil || fmt.Sprint(order(iterated)) != "[1 2 3]" {
+		t.Fatalf("iterator saw %v, err %v", order(iterated), it.Err())
+	}
+
+	got, err := Query("custom", "archive", QueryFilter{Since: base.Add(-time.Second)})
+	if err != nil || fmt.Sprint(order(got)) != "[3 2 1]" {
+		t.Fatalf("query returned %v, err %v", order(got), err)
+	}
+	latest, err := ReadLatest("custom", "archive")
+	if err != nil || latest.Payload["n"] != float64(3) {
+		t.Fatalf("latest = %v, err %v", latest.Payload, err)
+	}
+	// Paging counts files, and the bundle was written first.
+	page, total, err := ListChannelPage("custom", "archive", 0, 1)
+	if err != nil || total != 2 || fmt.Sprint(order(page)) != "[1 3]" {
+		t.Fatalf("page = %v of %d, err %v", order(page), total, err)
+	}
+}
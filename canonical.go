@@ -0,0 +1,69 @@
+package interband
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// CanonicalJSON encodes v deterministically, for hashing and signing: object
+// keys sorted at every depth (struct fields included), no insignificant
+// whitespace, HTML characters unescaped, and every number in the form
+// encoding/json gives a float64 (1.0 and 1e0 both become 1). Integers beyond
+// 2^53 lose precision, as they do in NormalizePayload. Values with NaN or
+// infinities fail. WriteDedup keys and envelope signatures are computed from
+// this form.
+func CanonicalJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("canonical JSON: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("canonical JSON: %w", err)
+	}
+	if tree, err = canonicalNumbers(tree); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// encoding/json writes map keys in sorted order at every depth.
+	if err := enc.Encode(tree); err != nil {
+		return nil, fmt.Errorf("canonical JSON: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// canonicalNumbers replaces every json.Number in a decoded tree with its
+// float64 value.
+func canonicalNumbers(v any) (any, error) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("canonical JSON: number %s out of range", x)
+		}
+		return f, nil
+	case map[string]any:
+		for k, item := range x {
+			n, err := canonicalNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			x[k] = n
+		}
+	case []any:
+		for idx, item := range x {
+			n, err := canonicalNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			x[idx] = n
+		}
+	}
+	return v, nil
+}
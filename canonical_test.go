@@ -0,0 +1,45 @@
+package interband
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	a := map[string]any{}
+	a["zeta"] = 1
+	a["alpha"] = map[string]any{"y": []any{2.0, "<b>"}, "x": true}
+	a["mid"] = json.Number("3.0")
+	b := map[string]any{}
+	b["mid"] = 3
+	b["alpha"] = map[string]any{"x": true, "y": []any{json.Number("2"), "<b>"}}
+	b["zeta"] = 1.0
+
+	ca, err := CanonicalJSON(a)
+	if err != nil {
+		t.Fatalf("canonicalize a: %v", err)
+	}
+	cb, err := CanonicalJSON(b)
+	if err != nil {
+		t.Fatalf("canonicalize b: %v", err)
+	}
+	want := `{"alpha":{"x":true,"y":[2,"<b>"]},"mid":3,"zeta":1}`
+	if string(ca) != want || string(cb) != want {
+		t.Fatalf("canonical forms differ:\n%s\n%s\nwant %s", ca, cb, want)
+	}
+
+	type point struct {
+		Y int `json:"y"`
+		X int `json:"x"`
+	}
+	if got, err := CanonicalJSON(point{Y: 1, X: 2}); err != nil || string(got) != `{"x":2,"y":1}` {
+		t.Fatalf("struct fields should be sorted, got %s, %v", got, err)
+	}
+	if _, err := CanonicalJSON(map[string]any{"n": math.NaN()}); err == nil {
+		t.Fatal("expected NaN to be rejected")
+	}
+	if _, err := CanonicalJSON(json.Number("1e400")); err == nil {
+		t.Fatal("expected an out-of-range number to be rejected")
+	}
+}
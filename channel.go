@@ -0,0 +1,375 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReservedPrefix starts every file name interband keeps for itself, such as
+// temp files, the prune stamp, and the channel lock. Listing, iteration,
+// watching, and pruning skip reserved files, so auxiliary metadata can live in
+// a channel directory under a name like ".interband-meta.json".
+const ReservedPrefix = ".interband"
+
+// IsReservedName reports whether a file name in a channel directory is
+// reserved and never treated as an envelope.
+func IsReservedName(name string) bool {
+	return strings.HasPrefix(name, ReservedPrefix)
+}
+
+// channelEntry describes one envelope file in a channel directory.
+type channelEntry struct {
+	name    string
+	path    string
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// Exists reports whether a message is stored for key. A missing file is not an
+// error; any other stat failure is returned.
+func Exists(namespace, channel, key string) (bool, error) {
+	return envClient().Exists(namespace, channel, key)
+}
+
+func (c *Client) Exists(namespace, channel, key string) (bool, error) {
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return false, err
+	}
+	if _, _, err := c.statKeyFile(p); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// StatKey returns file metadata for the message stored for key, plain or
+// compressed, without decoding it. A missing key returns an error matching
+// ErrNotFound.
+func StatKey(namespace, channel, key string) (os.FileInfo, error) {
+	return envClient().StatKey(namespace, channel, key)
+}
+
+func (c *Client) StatKey(namespace, channel, key string) (os.FileInfo, error) {
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return nil, err
+	}
+	info, _, err := c.statKeyFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Delete removes the message stored for key, plain or compressed. A missing
+// key returns an error matching ErrNotFound.
+func Delete(namespace, channel, key string) error {
+	return envClient().Delete(namespace, channel, key)
+}
+
+func (c *Client) Delete(namespace, channel, key string) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return err
+	}
+	_, actual, err := c.statKeyFile(p)
+	if err != nil {
+		return err
+	}
+	if err := c.backend().Remove(actual); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return notFound(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteMatching removes every key in a channel whose sanitized name matches
+// pattern (filepath.Match syntax) and returns how many files were removed.
+// Removal continues past individual failures, which are returned joined.
+func DeleteMatching(namespace, channel, pattern string) (int, error) {
+	return envClient().DeleteMatching(namespace, channel, pattern)
+}
+
+func (c *Client) DeleteMatching(namespace, channel, pattern string) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var errs []error
+	for _, entry := range entries {
+		if ok, _ := filepath.Match(pattern, entry.key); !ok {
+			continue
+		}
+		if err := c.backend().Remove(entry.path); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// ClearChannel removes every message in a channel, including compressed
+// files, files in layout buckets, and LogWriter segments, and returns how many
+// it removed. Temp files and the prune stamp go too, uncounted; the directory
+// and other reserved files, such as the lock and Append's sequence, stay. It
+// holds the channel lock, so it must not be called from inside
+// WithChannelLock for the same channel. Files that vanish during the sweep
+// are ignored, and other removal failures are returned joined.
+func ClearChannel(namespace, channel string) (int, error) {
+	return envClient().ClearChannel(namespace, channel)
+}
+
+func (c *Client) ClearChannel(namespace, channel string) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	b := c.backend()
+	if _, err := b.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	var errs []error
+	remove := func(path string, _ int64) bool {
+		if err := b.Remove(path); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			return false
+		}
+		return true
+	}
+	removed := 0
+	err = c.WithChannelLock(namespace, channel, func() error {
+		entries, err := c.readChannelEntries(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if remove(entry.path, entry.size) {
+				removed++
+			}
+		}
+		segments, err := c.readSegments(dir)
+		if err != nil {
+			return err
+		}
+		for _, seg := range segments {
+			if remove(seg.path, 0) {
+				removed++
+			}
+		}
+		all, err := b.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		_, tempErrs := c.removeTempFiles(dir, all, time.Time{}, remove)
+		errs = append(errs, tempErrs...)
+		remove(pruneStampPath(dir), 0)
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Namespaces lists the namespace directories under Root, sorted. Hidden
+// entries and plain files are ignored, and a missing Root yields an empty
+// slice.
+func Namespaces() ([]string, error) {
+	return envClient().Namespaces()
+}
+
+func (c *Client) Namespaces() ([]string, error) {
+	names, err := c.listSubdirs(c.cfg.Root)
+	if names == nil {
+		names = []string{}
+	}
+	return names, err
+}
+
+// Channels lists the channel directories in a namespace, sorted, ignoring
+// hidden entries and plain files. A missing namespace yields an empty slice.
+func Channels(namespace string) ([]string, error) {
+	return envClient().Channels(namespace)
+}
+
+func (c *Client) Channels(namespace string) ([]string, error) {
+	if strings.TrimSpace(namespace) == "" {
+		return nil, errors.New("namespace is required")
+	}
+	if err := checkSegment("namespace", namespace); err != nil {
+		return nil, err
+	}
+	names, err := c.listSubdirs(filepath.Join(c.cfg.Root, namespace))
+	if names == nil {
+		names = []string{}
+	}
+	return names, err
+}
+
+// readChannelEntries lists envelope files in dir and in the layout's bucket
+// subdirectories, skipping reserved files and other subdirectories. A missing
+// directory yields no entries.
+func (c *Client) readChannelEntries(dir string) ([]channelEntry, error) {
+	return c.readEntries(dir, true)
+}
+
+func (c *Client) readEntries(dir string, buckets bool) ([]channelEntry, error) {
+	entries, err := c.backend().ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out := make([]channelEntry, 0, len(entries))
+	layout := c.layout()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if buckets && layout.IsBucket(entry.Name()) {
+				nested, err := c.readEntries(filepath.Join(dir, entry.Name()), false)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, nested...)
+			}
+			continue
+		}
+		key, ok := envelopeKey(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, channelEntry{
+			name:    entry.Name(),
+			path:    filepath.Join(dir, entry.Name()),
+			key:     key,
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		})
+	}
+	return out, nil
+}
+
+// envelopeHeader is the envelope without its payload, for scans that only
+// need the wrapper fields.
+type envelopeHeader struct {
+	Version   string `json:"version"`
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+	Timestamp string `json:"timestamp"`
+	Key       string `json:"key"`
+	Payload   struct {
+		ExpiresAt any `json:"expires_at"`
+	} `json:"payload"`
+}
+
+func (c *Client) readHeader(sourcePath string) (envelopeHeader, error) {
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return envelopeHeader{}, err
+	}
+	var hdr envelopeHeader
+	if err := json.Unmarshal(data, &hdr); err != nil {
+		return envelopeHeader{}, err
+	}
+	return hdr, nil
+}
+
+// readHeaders reads the headers of a channel file: one for an envelope, or
+// one per item for a bundle.
+func (c *Client) readHeaders(sourcePath string) ([]envelopeHeader, error) {
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	if isBundle(data) {
+		var hdrs []envelopeHeader
+		if err := json.Unmarshal(data, &hdrs); err != nil {
+			return nil, err
+		}
+		return hdrs, nil
+	}
+	var hdr envelopeHeader
+	if err := json.Unmarshal(data, &hdr); err != nil {
+		return nil, err
+	}
+	return []envelopeHeader{hdr}, nil
+}
+
+// TypesInNamespace tallies the envelope types stored across every channel of a
+// namespace. Files that cannot be decoded are skipped.
+func TypesInNamespace(namespace string) (map[string]int, error) {
+	return envClient().TypesInNamespace(namespace)
+}
+
+func (c *Client) TypesInNamespace(namespace string) (map[string]int, error) {
+	if strings.TrimSpace(namespace) == "" {
+		return nil, errors.New("namespace is required")
+	}
+	if err := checkSegment("namespace", namespace); err != nil {
+		return nil, err
+	}
+	channels, err := c.listSubdirs(filepath.Join(c.cfg.Root, namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, ch := range channels {
+		dir, err := c.ChannelDir(namespace, ch)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := c.readChannelEntries(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			hdr, err := c.readHeader(entry.path)
+			if err != nil || hdr.Type == "" {
+				continue
+			}
+			counts[hdr.Type]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,301 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExists(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	ok, err := Exists("custom", "events", "k")
+	if err != nil || ok {
+		t.Fatalf("expected missing key, ok=%v err=%v", ok, err)
+	}
+
+	p, err := Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	ok, err = Exists("custom", "events", "k")
+	if err != nil || !ok {
+		t.Fatalf("expected existing key, ok=%v err=%v", ok, err)
+	}
+
+	if _, err := Exists("", "events", "k"); err == nil {
+		t.Fatal("expected error for empty namespace")
+	}
+}
+
+func TestStatKey(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	if _, err := StatKey("custom", "events", "k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	p, err := Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	info, err := StatKey("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if info.Name() != "k.json" || info.Size() == 0 || time.Since(info.ModTime()) > time.Minute {
+		t.Fatalf("unexpected info: %s %d %v", info.Name(), info.Size(), info.ModTime())
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	p, err := Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := Delete("custom", "events", "k"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if ok, _ := Exists("custom", "events", "k"); ok {
+		t.Fatal("expected key to be gone")
+	}
+	err = Delete("custom", "events", "k")
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := Delete("custom", "", "k"); err == nil {
+		t.Fatal("expected error for empty channel")
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	for _, key := range []string{"sess1-a", "sess1-b", "sess2-a"} {
+		p, err := Path("custom", "events", key)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := Write(p, "custom", "anything", "sess", map[string]any{"k": key}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	n, err := DeleteMatching("custom", "events", "sess1-*")
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 removals, n=%d err=%v", n, err)
+	}
+	if ok, _ := Exists("custom", "events", "sess2-a"); !ok {
+		t.Fatal("expected non-matching key to remain")
+	}
+
+	n, err = DeleteMatching("custom", "events", "sess1-*")
+	if err != nil || n != 0 {
+		t.Fatalf("expected idempotent second pass, n=%d err=%v", n, err)
+	}
+
+	if _, err := DeleteMatching("custom", "events", "["); err == nil {
+		t.Fatal("expected bad pattern error")
+	}
+}
+
+func TestClearChannel(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	for _, key := range []string{"a", "b"} {
+		p, _ := Path("custom", "events", key)
+		if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	p, _ := Path("custom", "events", "c")
+	if err := WriteCompressed(p+CompressedExt, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("compressed write failed: %v", err)
+	}
+	if _, err := Append("custom", "events", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := PruneChannel("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	dir, _ := ChannelDir("custom", "events")
+	meta := filepath.Join(dir, ".interband-meta.json")
+	temp := filepath.Join(dir, tempFilePrefix+"inflight")
+	for _, name := range []string{meta, temp} {
+		if err := os.WriteFile(name, []byte("{}"), 0o600); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	n, err := ClearChannel("custom", "events")
+	if err != nil || n != 4 {
+		t.Fatalf("expected 4 messages cleared, got %d %v", n, err)
+	}
+	if envs, _, _ := ListChannel("custom", "events"); len(envs) != 0 {
+		t.Fatalf("channel not empty: %+v", envs)
+	}
+	for _, gone := range []string{temp, pruneStampPath(dir)} {
+		if _, err := os.Stat(gone); !os.IsNotExist(err) {
+			t.Fatalf("%s should be removed, got %v", gone, err)
+		}
+	}
+	for _, kept := range []string{dir, meta, seqPath(dir)} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Fatalf("%s should be kept: %v", kept, err)
+		}
+	}
+	if key, _ := Append("custom", "events", "anything", "s", map[string]any{}); key != "0000002" {
+		t.Fatalf("sequence should survive a clear, got %q", key)
+	}
+
+	if n, err := ClearChannel("custom", "missing"); err != nil || n != 0 {
+		t.Fatalf("missing channel should be a no-op: %d %v", n, err)
+	}
+}
+
+func TestTypesInNamespace(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	writes := []struct{ channel, key, typ string }{
+		{"a", "1", "alpha"},
+		{"a", "2", "alpha"},
+		{"b", "1", "beta"},
+	}
+	for _, w := range writes {
+		p, err := Path("custom", w.channel, w.key)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := Write(p, "custom", w.typ, "sess", map[string]any{"k": "v"}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	counts, err := TypesInNamespace("custom")
+	if err != nil {
+		t.Fatalf("types failed: %v", err)
+	}
+	if counts["alpha"] != 2 || counts["beta"] != 1 || len(counts) != 2 {
+		t.Fatalf("unexpected counts: %#v", counts)
+	}
+
+	counts, err = TypesInNamespace("missing")
+	if err != nil || len(counts) != 0 {
+		t.Fatalf("expected empty tally for missing namespace, counts=%#v err=%v", counts, err)
+	}
+}
+
+func TestNamespacesAndChannels(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "not-yet")
+	t.Setenv("INTERBAND_ROOT", root)
+
+	if ns, err := Namespaces(); err != nil || ns == nil || len(ns) != 0 {
+		t.Fatalf("expected empty namespaces for missing root, got %v %v", ns, err)
+	}
+	if ch, err := Channels("custom"); err != nil || ch == nil || len(ch) != 0 {
+		t.Fatalf("expected empty channels for missing namespace, got %v %v", ch, err)
+	}
+
+	for _, ref := range []ChannelRef{{"custom", "b"}, {"custom", "a"}, {"other", "x"}} {
+		p, _ := Path(ref.Namespace, ref.Channel, "k")
+		if err := Write(p, ref.Namespace, "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := PruneChannel("custom", "a"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".hidden"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "stray.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	ns, err := Namespaces()
+	if err != nil || len(ns) != 2 || ns[0] != "custom" || ns[1] != "other" {
+		t.Fatalf("unexpected namespaces: %v %v", ns, err)
+	}
+	ch, err := Channels("custom")
+	if err != nil || len(ch) != 2 || ch[0] != "a" || ch[1] != "b" {
+		t.Fatalf("unexpected channels: %v %v", ch, err)
+	}
+	if _, err := Channels(""); err == nil {
+		t.Fatal("expected error for empty namespace")
+	}
+}
+
+func TestReservedFilesAreIgnored(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+	t.Setenv("INTERBAND_RETENTION_SECS", "60")
+	t.Setenv("INTERBAND_MAX_FILES", "1")
+
+	p, _ := Path("custom", "events", "k")
+	if err := Write(p, "custom", "anything", "s", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	// A well-formed envelope under a reserved name, old enough to expire.
+	raw, _ := os.ReadFile(p)
+	meta := filepath.Join(filepath.Dir(p), ".interband-meta.json")
+	if err := os.WriteFile(meta, raw, 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(meta, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	if envs, skipped, _ := ListChannel("custom", "events"); len(envs) != 1 || len(skipped) != 0 {
+		t.Fatalf("ListChannel saw reserved file: %d envs, %v", len(envs), skipped)
+	}
+	it, _ := NewChannelIterator("custom", "events")
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 1 || it.Err() != nil {
+		t.Fatalf("iterator saw reserved file: %d %v", n, it.Err())
+	}
+	if envs, _ := Query("custom", "events", QueryFilter{}); len(envs) != 1 {
+		t.Fatalf("Query saw reserved file: %d", len(envs))
+	}
+	if counts, _ := TypesInNamespace("custom"); counts["anything"] != 1 {
+		t.Fatalf("TypesInNamespace saw reserved file: %v", counts)
+	}
+	if u, _ := ChannelUsage("custom", "events"); u.Files != 1 {
+		t.Fatalf("ChannelUsage saw reserved file: %+v", u)
+	}
+	if n, _ := DeleteMatching("custom", "events", "*"); n != 1 {
+		t.Fatalf("DeleteMatching removed %d files", n)
+	}
+	if err := Write(p, "custom", "anything", "s", map[string]any{"n": 2}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if stats, err := PruneChannelStats("custom", "events"); err != nil || stats.Removed() != 0 || stats.Remaining != 1 {
+		t.Fatalf("prune touched reserved file: %+v %v", stats, err)
+	}
+	if _, err := os.Stat(meta); err != nil {
+		t.Fatalf("reserved file was removed: %v", err)
+	}
+
+	if _, err := Path("custom", "events", ".interband-meta"); err == nil {
+		t.Fatal("expected reserved key to be rejected")
+	}
+	if !IsReservedName(".interband-prune.stamp") || IsReservedName("interband.json") {
+		t.Fatal("IsReservedName misclassified a name")
+	}
+}
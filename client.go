@@ -0,0 +1,306 @@
+package interband
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ChannelRef names one channel within a namespace.
+type ChannelRef struct {
+	Namespace string
+	Channel   string
+}
+
+// Config holds everything a Client needs, replacing the INTERBAND_*
+// environment variables read by the package-level functions.
+type Config struct {
+	// Root is the directory holding all namespaces. Empty means ~/.interband.
+	Root string
+	// ProtocolVersion is stamped on envelopes written with Write. Empty means
+	// the current protocol version.
+	ProtocolVersion string
+	// TimestampPrecision is "s", "ms", "us", or "ns". Empty means "ns".
+	TimestampPrecision string
+	// RetentionSeconds and MaxFiles override the built-in channel defaults.
+	RetentionSeconds map[ChannelRef]int
+	MaxFiles         map[ChannelRef]int
+	// PruneInterval throttles PruneChannel per channel. Zero prunes on every call.
+	PruneInterval time.Duration
+	// PollInterval is how often watchers rescan a channel. Zero means 500ms.
+	PollInterval time.Duration
+	// StrictReads rejects unknown bead phases on read instead of logging them.
+	StrictReads bool
+	// StrictVersion rejects envelopes whose minor version is newer than
+	// CurrentVersion. By default any 1.x.y envelope is read.
+	StrictVersion bool
+	// RequireUTC rejects RFC 3339 timestamps with an offset other than UTC
+	// on read and in WriteEnvelope and WriteBundle, like the RequireUTC
+	// option to ValidateEnvelope.
+	RequireUTC bool
+	// UnicodeKeys makes Path sanitize keys with SafeKeyUnicode instead of
+	// SafeKey.
+	UnicodeKeys bool
+	// UniqueKeys makes Path append a short hash of the raw key whenever
+	// sanitizing was lossy, as SafeKeyUnique does.
+	UniqueKeys bool
+	// PortableKeys makes Path escape names Windows reserves, as
+	// SafeKeyPortable does.
+	PortableKeys bool
+	// MaxKeyBytes caps the length of sanitized keys. Longer keys are cut and
+	// suffixed with a hash of the raw key, so distinct keys stay distinct.
+	// Zero, the default, disables the limit; keys too long for a file name
+	// then fail with ErrValidation. The bash helpers never cut keys, so
+	// enable it only where bash does not share the channel.
+	MaxKeyBytes int
+	// MaxPayloadBytes caps the encoded size of an envelope on write and the
+	// file size on read. Zero means DefaultMaxPayloadBytes (4 MiB); a
+	// negative value disables the limit.
+	MaxPayloadBytes int64
+	// PruneByTimestamp makes pruning age files by their envelope timestamp
+	// instead of their modification time. Each file is read during prune.
+	PruneByTimestamp bool
+	// PruneGrace protects files modified within this window from pruning,
+	// whatever their envelope timestamp, so a just-written message survives
+	// until consumers have had a chance to see it. Protected files still count
+	// toward MaxFiles. Zero disables the window.
+	PruneGrace time.Duration
+	// MaxPruneRemovals caps the files one prune of a channel removes, so a
+	// large backlog drains over several prunes instead of stalling the
+	// caller that triggered one. Zero means no cap.
+	MaxPruneRemovals int
+	// HonorExpiry makes reads fail with ErrExpired, listings and queries
+	// skip, and pruning remove envelopes whose payload expires_at has passed.
+	HonorExpiry bool
+	// FileMode and DirMode set the permissions of envelopes and of channel
+	// directories the default backend creates, e.g. 0o640 and
+	// os.ModeSetgid|0o775 for a shared group directory. They are applied with
+	// chmod, so the umask does not narrow them. Zero keeps the defaults
+	// (0600 files, 0755 directories less the umask).
+	FileMode os.FileMode
+	DirMode  os.FileMode
+	// CheckTransitions makes writes of interphase/bead_phase messages fail
+	// validation when they move the bead replaced at the same path
+	// backwards, per ValidPhaseTransition.
+	CheckTransitions bool
+	// ReadOnly makes every operation that would change the store, from
+	// writes to pruning, fail with ErrReadOnly before touching it. Reads are
+	// unaffected.
+	ReadOnly bool
+	// Clock supplies write timestamps and the time prune decisions are made
+	// at. Nil means the system clock. File ages are still read from the
+	// backend, so pair a fake clock with MemBackend.Clock or Chtimes.
+	Clock Clock
+	// AutoSessionID stamps SessionID() on envelopes written or built with an
+	// empty session ID. Explicit session IDs are kept.
+	AutoSessionID bool
+	// Layout places channels and keys on the backend. Nil means FlatLayout.
+	Layout Layout
+	// Observer receives metrics callbacks. Nil means the one installed with
+	// SetObserver, if any.
+	Observer Observer
+	// OnSkip is told about each file ListChannel, ReadChannelMap, Query,
+	// ReadLatest, Tail, and channel iterators skip as unreadable. Nil means the handler
+	// installed with SetSkipHandler, if any.
+	OnSkip SkipHandler
+	// Backend stores the envelopes. Nil means OSBackend with FileMode and
+	// DirMode.
+	Backend Backend
+}
+
+// DefaultConfig returns the configuration used when no environment overrides
+// are set.
+func DefaultConfig() Config {
+	return Config{
+		Root:            defaultRoot(),
+		ProtocolVersion: "1.0.0",
+		PruneInterval:   300 * time.Second,
+		PollInterval:    500 * time.Millisecond,
+		MaxPayloadBytes: DefaultMaxPayloadBytes,
+	}
+}
+
+// DefaultMaxKeyBytes is the suggested value for Config.MaxKeyBytes and
+// INTERBAND_MAX_KEY_BYTES. It leaves room for the ".json.gz" extension within
+// the common 255-byte name limit.
+const DefaultMaxKeyBytes = 200
+
+// maxNameBytes is the common file name length limit.
+const maxNameBytes = 255
+
+// DefaultMaxPayloadBytes is the envelope size limit unless
+// INTERBAND_MAX_PAYLOAD_BYTES or Config.MaxPayloadBytes says otherwise.
+const DefaultMaxPayloadBytes = 4 << 20
+
+// Client performs interband operations against one Config. The package-level
+// functions use a client seeded from the environment on every call.
+type Client struct {
+	cfg Config
+	// env makes per-channel limits fall back to INTERBAND_* variables before
+	// the built-in defaults, as the package-level functions always have.
+	env bool
+}
+
+// NewClient returns a client for cfg. Empty Root and ProtocolVersion, and zero
+// PollInterval and MaxPayloadBytes, fall back to their defaults.
+func NewClient(cfg Config) *Client {
+	if strings.TrimSpace(cfg.Root) == "" {
+		cfg.Root = defaultRoot()
+	}
+	if strings.TrimSpace(cfg.ProtocolVersion) == "" {
+		cfg.ProtocolVersion = "1.0.0"
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 500 * time.Millisecond
+	}
+	if cfg.MaxPayloadBytes == 0 {
+		cfg.MaxPayloadBytes = DefaultMaxPayloadBytes
+	}
+	return &Client{cfg: cfg}
+}
+
+// Config returns the client's configuration.
+func (c *Client) Config() Config {
+	return c.cfg
+}
+
+// Root returns the client's root directory.
+func (c *Client) Root() string {
+	return c.cfg.Root
+}
+
+// backend returns the configured storage, defaulting to the filesystem.
+func (c *Client) backend() Backend {
+	if c.cfg.Backend == nil {
+		return OSBackend{FileMode: c.cfg.FileMode, DirMode: c.cfg.DirMode}
+	}
+	return c.cfg.Backend
+}
+
+// writable fails with ErrReadOnly on a read-only client.
+func (c *Client) writable() error {
+	if c.cfg.ReadOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// safeKey sanitizes a raw key into a file name stem per the client's config.
+func (c *Client) safeKey(raw string) string {
+	key := SafeKey(raw)
+	if c.cfg.UnicodeKeys {
+		key = SafeKeyUnicode(raw)
+	}
+	if c.cfg.PortableKeys {
+		key = portableKey(key)
+	}
+	if c.cfg.UniqueKeys {
+		key = withKeyHash(raw, key)
+	}
+	return limitKey(raw, key, c.cfg.MaxKeyBytes)
+}
+
+// limitKey cuts key to at most limit bytes, on a rune boundary, replacing the
+// tail with "-" and a hash of raw. A limit of zero or less, or one too small
+// to hold the hash, leaves key unchanged.
+func limitKey(raw, key string, limit int) string {
+	const suffixLen = 1 + 16
+	if limit <= suffixLen || len(key) <= limit {
+		return key
+	}
+	cut := limit - suffixLen
+	for cut > 0 && !utf8.RuneStart(key[cut]) {
+		cut--
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return key[:cut] + "-" + hex.EncodeToString(sum[:8])
+}
+
+func envClient() *Client {
+	pruneInterval := 300
+	if v, ok := parseEnvInt("INTERBAND_PRUNE_INTERVAL_SECS"); ok {
+		pruneInterval = v
+	}
+	if pruneInterval < 0 {
+		pruneInterval = 0
+	}
+	maxRemovals, _ := parseEnvInt("INTERBAND_PRUNE_MAX_REMOVALS")
+	grace, _ := parseEnvInt("INTERBAND_PRUNE_GRACE_SECS")
+	if grace < 0 {
+		grace = 0
+	}
+	maxKey, _ := parseEnvInt("INTERBAND_MAX_KEY_BYTES")
+	maxPayload := int64(DefaultMaxPayloadBytes)
+	if v, ok := parseEnvInt("INTERBAND_MAX_PAYLOAD_BYTES"); ok {
+		maxPayload = int64(v)
+	}
+	if maxPayload == 0 {
+		// The variable disables the limit with 0, which Config spells -1.
+		maxPayload = -1
+	}
+	c := NewClient(Config{
+		Root:               Root(),
+		ProtocolVersion:    ProtocolVersion(),
+		TimestampPrecision: os.Getenv("INTERBAND_TIMESTAMP_PRECISION"),
+		PruneInterval:      time.Duration(pruneInterval) * time.Second,
+		PollInterval:       PollInterval(),
+		StrictReads:        strictReads(),
+		StrictVersion:      envFlag("INTERBAND_STRICT_VERSION"),
+		RequireUTC:         envFlag("INTERBAND_REQUIRE_UTC"),
+		UnicodeKeys:        envFlag("INTERBAND_UNICODE_KEYS"),
+		UniqueKeys:         envFlag("INTERBAND_UNIQUE_KEYS"),
+		PortableKeys:       envFlag("INTERBAND_PORTABLE_KEYS"),
+		MaxPayloadBytes:    maxPayload,
+		MaxKeyBytes:        maxKey,
+		PruneByTimestamp:   envFlag("INTERBAND_PRUNE_BY_TIMESTAMP"),
+		PruneGrace:         time.Duration(grace) * time.Second,
+		MaxPruneRemovals:   maxRemovals,
+		HonorExpiry:        envFlag("INTERBAND_HONOR_EXPIRY"),
+		ReadOnly:           envFlag("INTERBAND_READONLY"),
+		CheckTransitions:   envFlag("INTERBAND_CHECK_PHASE_TRANSITIONS"),
+		FileMode:           envMode("INTERBAND_FILE_MODE"),
+		DirMode:            envMode("INTERBAND_DIR_MODE"),
+		AutoSessionID:      envFlag("INTERBAND_AUTO_SESSION_ID"),
+		Layout:             envLayout(os.Getenv("INTERBAND_LAYOUT")),
+	})
+	c.env = true
+	return c
+}
+
+// envMode parses an octal permission such as "0640" or "2775" from the
+// environment, mapping the setuid, setgid, and sticky bits onto os.FileMode.
+// Unset or invalid values yield zero.
+func envMode(name string) os.FileMode {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.ParseUint(raw, 8, 32)
+	if err != nil || v > 0o7777 {
+		return 0
+	}
+	mode := os.FileMode(v & 0o777)
+	if v&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if v&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if v&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+func defaultRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil || strings.TrimSpace(home) == "" {
+		return ".interband"
+	}
+	return filepath.Join(home, ".interband")
+}
@@ -0,0 +1,57 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientsAreIndependent(t *testing.T) {
+	cfgA := DefaultConfig()
+	cfgA.Root = t.TempDir()
+	cfgA.ProtocolVersion = "1.4.0"
+	cfgA.PruneInterval = 0
+	cfgA.RetentionSeconds = map[ChannelRef]int{{"custom", "events"}: 1}
+
+	cfgB := DefaultConfig()
+	cfgB.Root = t.TempDir()
+
+	a, b := NewClient(cfgA), NewClient(cfgB)
+
+	pa, err := a.Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if filepath.Dir(filepath.Dir(filepath.Dir(pa))) != cfgA.Root {
+		t.Fatalf("path %q not under client root %q", pa, cfgA.Root)
+	}
+	if err := a.Write(pa, "custom", "anything", "s", map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	env, err := a.ReadEnvelope(pa)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if env.Version != "1.4.0" {
+		t.Fatalf("expected client protocol version, got %q", env.Version)
+	}
+	if ok, _ := b.Exists("custom", "events", "k"); ok {
+		t.Fatal("expected second client not to see first client's root")
+	}
+
+	if a.RetentionSeconds("custom", "events") != 1 || b.RetentionSeconds("custom", "events") != 86400 {
+		t.Fatalf("unexpected retention: a=%d b=%d", a.RetentionSeconds("custom", "events"), b.RetentionSeconds("custom", "events"))
+	}
+
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(pa, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+	if err := a.PruneChannel("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if ok, _ := a.Exists("custom", "events", "k"); ok {
+		t.Fatal("expected client retention override to prune the file")
+	}
+}
@@ -0,0 +1,52 @@
+package interband
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time for envelope timestamps and prune
+// decisions. Config.Clock is nil by default, which uses the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// FakeClock is a Clock that only moves when told to, for deterministic tests.
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewFakeClock returns a FakeClock stopped at t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{t: t}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.t
+}
+
+// Set moves the clock to t.
+func (f *FakeClock) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = t
+}
+
+// Advance moves the clock forward by d.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = f.t.Add(d)
+}
+
+// now returns the client's current time.
+func (c *Client) now() time.Time {
+	if c.cfg.Clock != nil {
+		return c.cfg.Clock.Now()
+	}
+	return time.Now()
+}
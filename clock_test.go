@@ -0,0 +1,73 @@
+package interband
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockDrivesWritesAndPruning(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	mem := NewMemBackend()
+	mem.Clock = clock
+
+	cfg := DefaultConfig()
+	cfg.Root = "/mem"
+	cfg.Backend = mem
+	cfg.Clock = clock
+	cfg.PruneInterval = time.Hour
+	cfg.RetentionSeconds = map[ChannelRef]int{{Namespace: "custom", Channel: "events"}: 7200}
+	c := NewClient(cfg)
+
+	p, _ := c.Path("custom", "events", "k")
+	if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	env, err := c.ReadEnvelope(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if at, _ := env.Time(); !at.Equal(start) {
+		t.Fatalf("timestamp %s does not come from the clock", env.Timestamp)
+	}
+
+	if stats, _ := c.PruneChannelStats("custom", "events"); stats.Skipped || stats.Remaining != 1 {
+		t.Fatalf("first prune should run and keep the file: %+v", stats)
+	}
+	clock.Advance(59 * time.Minute)
+	if stats, _ := c.PruneChannelStats("custom", "events"); !stats.Skipped {
+		t.Fatalf("prune within the interval should be skipped: %+v", stats)
+	}
+	clock.Advance(2 * time.Minute)
+	if stats, _ := c.PruneChannelStats("custom", "events"); stats.Skipped || stats.Expired != 0 {
+		t.Fatalf("prune after the interval should run without expiring: %+v", stats)
+	}
+	clock.Advance(2 * time.Hour)
+	if stats, _ := c.PruneChannelStats("custom", "events"); stats.Expired != 1 {
+		t.Fatalf("file should expire once past retention: %+v", stats)
+	}
+}
+
+func TestFakeClockGatesPruneOnDisk(t *testing.T) {
+	clock := NewFakeClock(time.Now().Add(-24 * time.Hour))
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.Clock = clock
+	cfg.PruneInterval = time.Hour
+	c := NewClient(cfg)
+
+	p, _ := c.Path("custom", "events", "k")
+	if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if stats, _ := c.PruneChannelStats("custom", "events"); stats.Skipped {
+		t.Fatal("first prune should run")
+	}
+	if due, _ := c.ShouldPrune("custom", "events"); due {
+		t.Fatal("prune should not be due right after a prune")
+	}
+	clock.Advance(time.Hour)
+	if due, _ := c.ShouldPrune("custom", "events"); !due {
+		t.Fatal("prune should be due once the fake clock passes the interval")
+	}
+}
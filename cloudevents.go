@@ -0,0 +1,97 @@
+package interband
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CloudEventsSpecVersion is the CloudEvents specification version produced
+// by ToCloudEvent and required by EnvelopeFromCloudEvent.
+const CloudEventsSpecVersion = "1.0"
+
+// cloudEvent is the structured-mode JSON form of a CloudEvents 1.0 event.
+// The interband protocol version travels in the interbandversion extension
+// attribute so a round trip preserves it.
+type cloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Source          string         `json:"source"`
+	Type            string         `json:"type"`
+	Subject         string         `json:"subject,omitempty"`
+	Time            string         `json:"time,omitempty"`
+	DataContentType string         `json:"datacontenttype,omitempty"`
+	Data            map[string]any `json:"data"`
+	InterbandVer    string         `json:"interbandversion,omitempty"`
+}
+
+// ToCloudEvent encodes e as a structured-mode CloudEvents 1.0 JSON event:
+// Namespace becomes source, Type type, SessionID subject, Timestamp time and
+// Payload data. Each call generates a fresh random id. Epoch timestamps are
+// converted to RFC3339, which CloudEvents requires; nothing else is
+// validated here.
+func (e Envelope) ToCloudEvent() ([]byte, error) {
+	id, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	ts := e.Timestamp
+	if _, err := time.Parse(time.RFC3339Nano, ts); ts != "" && err != nil {
+		t, err := e.Time()
+		if err != nil {
+			return nil, err
+		}
+		ts = t.UTC().Format(time.RFC3339Nano)
+	}
+	return json.Marshal(cloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id,
+		Source:          e.Namespace,
+		Type:            e.Type,
+		Subject:         e.SessionID,
+		Time:            ts,
+		DataContentType: "application/json",
+		Data:            e.Payload,
+		InterbandVer:    e.Version,
+	})
+}
+
+// EnvelopeFromCloudEvent decodes a structured-mode CloudEvents 1.0 event back
+// into an Envelope, reversing ToCloudEvent. Events without an
+// interbandversion extension get CurrentVersion. The result is validated the
+// same way ReadEnvelope validates a file.
+func EnvelopeFromCloudEvent(data []byte) (Envelope, error) {
+	var ce cloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return Envelope{}, err
+	}
+	if ce.SpecVersion != CloudEventsSpecVersion {
+		return Envelope{}, fmt.Errorf("unsupported cloudevents specversion %q", ce.SpecVersion)
+	}
+	version := ce.InterbandVer
+	if version == "" {
+		version = CurrentVersion
+	}
+	env := Envelope{
+		Version:   version,
+		Namespace: ce.Source,
+		Type:      ce.Type,
+		SessionID: ce.Subject,
+		Timestamp: ce.Time,
+		Payload:   ce.Data,
+	}
+	if err := validateEnvelope(env, envClient().readOptions()); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+func newEventID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,53 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCloudEventRoundTrip(t *testing.T) {
+	env := Envelope{
+		Version:   "1.0.0",
+		Namespace: "interphase",
+		Type:      "bead_phase",
+		SessionID: "s1",
+		Timestamp: "2026-01-02T03:04:05Z",
+		Payload:   map[string]any{"id": "iv-1", "phase": "planned", "reason": "r", "ts": float64(1)},
+	}
+	data, err := env.ToCloudEvent()
+	if err != nil {
+		t.Fatalf("ToCloudEvent: %v", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if raw["specversion"] != "1.0" || raw["source"] != "interphase" || raw["type"] != "bead_phase" || raw["subject"] != "s1" || raw["time"] != env.Timestamp {
+		t.Fatalf("unexpected attributes: %v", raw)
+	}
+	if id, _ := raw["id"].(string); id == "" {
+		t.Fatalf("expected generated id, got %v", raw["id"])
+	}
+
+	back, err := EnvelopeFromCloudEvent(data)
+	if err != nil {
+		t.Fatalf("EnvelopeFromCloudEvent: %v", err)
+	}
+	if back.Version != env.Version || back.Namespace != env.Namespace || back.SessionID != env.SessionID || back.Timestamp != env.Timestamp || back.Payload["phase"] != "planned" {
+		t.Fatalf("round trip mismatch: %+v", back)
+	}
+	if err := ValidateEnvelope(back); err != nil {
+		t.Fatalf("round-tripped envelope invalid: %v", err)
+	}
+}
+
+func TestEnvelopeFromCloudEventRejectsInvalid(t *testing.T) {
+	bad := []byte(`{"specversion":"1.0","id":"x","source":"interphase","type":"bead_phase","time":"2026-01-02T03:04:05Z","data":{"id":"iv-1"}}`)
+	if _, err := EnvelopeFromCloudEvent(bad); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if _, err := EnvelopeFromCloudEvent([]byte(`{"specversion":"0.3","source":"a","type":"b","data":{}}`)); err == nil {
+		t.Fatal("expected specversion error")
+	}
+}
@@ -0,0 +1,179 @@
+package interband
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempFilePrefix starts the temp files atomic writes rename into place.
+const tempFilePrefix = ReservedPrefix + "-tmp."
+
+// TempFileGrace is how old a temp file must be before CompactChannel treats it
+// as left behind by a crashed writer rather than a write in progress.
+const TempFileGrace = time.Minute
+
+// CompactStats reports what CompactChannel removed.
+type CompactStats struct {
+	// TempFiles counts leftover temp files removed.
+	TempFiles int
+	// Duplicates counts older copies removed where a key was stored both
+	// plain and compressed.
+	Duplicates int
+	// BytesReclaimed is the total size of the removed files.
+	BytesReclaimed int64
+}
+
+// CompactChannel removes temp files older than TempFileGrace, which crashed
+// writers leave behind and pruning never touches, including those in layout
+// buckets. It also keeps only the newest file for each key stored both as
+// .json and .json.gz. It holds the channel lock, so it must not be called from
+// inside WithChannelLock for the same channel. Removal continues past
+// failures, which are returned joined.
+func CompactChannel(namespace, channel string) (CompactStats, error) {
+	return envClient().CompactChannel(namespace, channel)
+}
+
+func (c *Client) CompactChannel(namespace, channel string) (CompactStats, error) {
+	var stats CompactStats
+	if err := c.writable(); err != nil {
+		return stats, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return stats, err
+	}
+	b := c.backend()
+	if _, err := b.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+
+	var errs []error
+	remove := func(path string, size int64) bool {
+		if err := b.Remove(path); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			return false
+		}
+		stats.BytesReclaimed += size
+		return true
+	}
+
+	err = c.WithChannelLock(namespace, channel, func() error {
+		all, err := b.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		n, tempErrs := c.removeTempFiles(dir, all, c.now().Add(-TempFileGrace), remove)
+		stats.TempFiles += n
+		errs = append(errs, tempErrs...)
+
+		entries, err := c.readChannelEntries(dir)
+		if err != nil {
+			return err
+		}
+		newest := make(map[string]channelEntry, len(entries))
+		for _, entry := range entries {
+			prev, ok := newest[entry.key]
+			if !ok {
+				newest[entry.key] = entry
+				continue
+			}
+			stale := entry
+			if entry.modTime.After(prev.modTime) {
+				newest[entry.key], stale = entry, prev
+			}
+			if remove(stale.path, stale.size) {
+				stats.Duplicates++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	return stats, errors.Join(errs...)
+}
+
+// SweepTempFiles removes temp files in a channel, including its layout
+// buckets, whose modification time is more than olderThan ago, and returns how
+// many it removed. A non-positive olderThan means TempFileGrace. It takes no
+// lock, so it may be called from inside WithChannelLock; the age threshold is
+// what keeps it away from writes in progress.
+func SweepTempFiles(namespace, channel string, olderThan time.Duration) (int, error) {
+	return envClient().SweepTempFiles(namespace, channel, olderThan)
+}
+
+func (c *Client) SweepTempFiles(namespace, channel string, olderThan time.Duration) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	if olderThan <= 0 {
+		olderThan = TempFileGrace
+	}
+	b := c.backend()
+	all, err := b.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	var errs []error
+	remove := func(path string, _ int64) bool {
+		if err := b.Remove(path); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			return false
+		}
+		return true
+	}
+	n, tempErrs := c.removeTempFiles(dir, all, c.now().Add(-olderThan), remove)
+	return n, errors.Join(append(errs, tempErrs...)...)
+}
+
+// removeTempFiles calls remove for each temp file in dir, whose listing is
+// all, and in its layout buckets that was last modified at or before cutoff.
+// A zero cutoff matches temp files of any age. It returns how many removals
+// succeeded and any bucket listing failures.
+func (c *Client) removeTempFiles(dir string, all []fs.DirEntry, cutoff time.Time, remove func(string, int64) bool) (int, []error) {
+	var errs []error
+	dirs := []string{dir}
+	for _, entry := range all {
+		if entry.IsDir() && c.layout().IsBucket(entry.Name()) {
+			dirs = append(dirs, filepath.Join(dir, entry.Name()))
+		}
+	}
+	removed := 0
+	for _, d := range dirs {
+		listing := all
+		if d != dir {
+			var err error
+			if listing, err = c.backend().ReadDir(d); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		for _, entry := range listing {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), tempFilePrefix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || (!cutoff.IsZero() && info.ModTime().After(cutoff)) {
+				continue
+			}
+			if remove(filepath.Join(d, entry.Name()), info.Size()) {
+				removed++
+			}
+		}
+	}
+	return removed, errs
+}
@@ -0,0 +1,104 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompactChannel(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	p, _ := Path("custom", "events", "k")
+	if err := Write(p, "custom", "anything", "s", map[string]any{"v": "old"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(p, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+	if err := WriteCompressed(p+CompressedExt, "custom", "anything", "s", map[string]any{"v": "new"}); err != nil {
+		t.Fatalf("compressed write failed: %v", err)
+	}
+	other, _ := Path("custom", "events", "other")
+	if err := Write(other, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	dir := filepath.Dir(p)
+	stale := filepath.Join(dir, ".interband-tmp.crashed")
+	fresh := filepath.Join(dir, ".interband-tmp.inflight")
+	for _, name := range []string{stale, fresh} {
+		if err := os.WriteFile(name, []byte("partial"), 0o600); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+	plain, _ := os.Stat(p)
+
+	stats, err := CompactChannel("custom", "events")
+	if err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if stats.TempFiles != 1 || stats.Duplicates != 1 || stats.BytesReclaimed != plain.Size()+int64(len("partial")) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("in-flight temp file removed: %v", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("older duplicate should be gone, got %v", err)
+	}
+	env, err := ReadEnvelope(p + CompressedExt)
+	if err != nil || env.Payload["v"] != "new" {
+		t.Fatalf("newest copy should remain: %+v %v", env, err)
+	}
+	if ok, _ := Exists("custom", "events", "other"); !ok {
+		t.Fatal("unrelated key removed")
+	}
+
+	if stats, err := CompactChannel("custom", "missing"); err != nil || stats != (CompactStats{}) {
+		t.Fatalf("missing channel should be a no-op: %+v %v", stats, err)
+	}
+}
+
+func TestSweepTempFiles(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	p, _ := Path("custom", "events", "k")
+	if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	dir := filepath.Dir(p)
+	stale := filepath.Join(dir, ".interband-tmp.crashed")
+	fresh := filepath.Join(dir, ".interband-tmp.inflight")
+	for _, name := range []string{stale, fresh} {
+		if err := os.WriteFile(name, []byte("partial"), 0o600); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	n, err := SweepTempFiles("custom", "events", time.Hour)
+	if err != nil || n != 1 {
+		t.Fatalf("expected one temp file swept, got %d %v", n, err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale temp file should be gone, got %v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("in-flight temp file removed: %v", err)
+	}
+	if ok, _ := Exists("custom", "events", "k"); !ok {
+		t.Fatal("envelope removed")
+	}
+	if n, err := SweepTempFiles("custom", "missing", 0); err != nil || n != 0 {
+		t.Fatalf("missing channel should be a no-op: %d %v", n, err)
+	}
+}
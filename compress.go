@@ -0,0 +1,191 @@
+package interband
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// CompressedExt is appended to Path results for gzip-compressed envelopes.
+const CompressedExt = ".gz"
+
+// WriteCompressed writes an envelope like Write but gzip-compresses it. By
+// convention targetPath is a Path result with CompressedExt appended, which
+// listing and pruning recognize alongside plain .json files.
+func WriteCompressed(targetPath, namespace, typ, sessionID string, payload map[string]any) error {
+	return envClient().WriteCompressed(targetPath, namespace, typ, sessionID, payload)
+}
+
+func (c *Client) WriteCompressed(targetPath, namespace, typ, sessionID string, payload map[string]any) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	env, err := c.newEnvelope(targetPath, namespace, typ, sessionID, payload)
+	if err != nil {
+		return err
+	}
+	return c.writeCompressedEnvelope(targetPath, env)
+}
+
+// writeCompressedEnvelope is writeEnvelope with gzip compression.
+func (c *Client) writeCompressedEnvelope(targetPath string, env Envelope) error {
+	if err := validateEnvelope(env, c.writeOptions()); err != nil {
+		return c.observeValidation(err)
+	}
+	if err := c.checkPhaseTransition(targetPath, env); err != nil {
+		return c.observeValidation(err)
+	}
+	data, err := encodeEnvelope(env)
+	if err != nil {
+		return err
+	}
+	if err := checkSize(targetPath, int64(len(data)), c.cfg.MaxPayloadBytes); err != nil {
+		return err
+	}
+	compressed, err := gzipBytes(data)
+	if err != nil {
+		return err
+	}
+	if err := c.backend().WriteAtomic(context.Background(), targetPath, compressed); err != nil {
+		return err
+	}
+	c.observe(func(o Observer) { o.OnWrite(env.Namespace, env.Type, len(compressed)) })
+	return nil
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readEnvelopeFile reads sourcePath through the client's backend, enforcing
+// MaxPayloadBytes. The filesystem backend streams the file so an oversized
+// one is never fully loaded; other backends are checked by size up front.
+func (c *Client) readEnvelopeFile(sourcePath string) ([]byte, error) {
+	limit := c.cfg.MaxPayloadBytes
+	b := c.backend()
+	if _, ok := b.(OSBackend); ok {
+		return readEnvelopeFile(sourcePath, limit)
+	}
+	info, err := b.Stat(sourcePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, notFound(err)
+		}
+		return nil, err
+	}
+	if err := checkSize(sourcePath, info.Size(), limit); err != nil {
+		return nil, err
+	}
+	data, err := b.ReadFile(sourcePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, notFound(err)
+		}
+		return nil, err
+	}
+	return maybeGunzip(data, sourcePath, limit)
+}
+
+// readEnvelopeFile reads sourcePath, transparently decompressing it when it
+// starts with the gzip magic bytes regardless of its extension. A positive
+// limit caps both the file size and the decompressed size; oversized files
+// fail with ErrPayloadTooLarge before they are fully read.
+func readEnvelopeFile(sourcePath string, limit int64) ([]byte, error) {
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, notFound(err)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if limit > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSize(sourcePath, info.Size(), limit); err != nil {
+			return nil, err
+		}
+	}
+	data, err := readLimited(f, sourcePath, limit)
+	if err != nil {
+		return nil, err
+	}
+	return maybeGunzip(data, sourcePath, limit)
+}
+
+func maybeGunzip(data []byte, sourcePath string, limit int64) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readLimited(zr, sourcePath, limit)
+}
+
+// readLimited reads r to EOF, failing once more than limit bytes arrive.
+// A limit of zero or less reads without bound.
+func readLimited(r io.Reader, sourcePath string, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(&io.LimitedReader{R: r, N: limit + 1})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(sourcePath, int64(len(data)), limit); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// envelopeKey returns the key stored in a channel file name and whether the
+// name is an envelope file at all.
+func envelopeKey(name string) (string, bool) {
+	if IsReservedName(name) {
+		return "", false
+	}
+	if key, ok := strings.CutSuffix(name, ".json"+CompressedExt); ok {
+		return key, true
+	}
+	if key, ok := strings.CutSuffix(name, ".json"); ok {
+		return key, true
+	}
+	return "", false
+}
+
+// statKeyFile stats the plain or compressed file for a key path.
+func (c *Client) statKeyFile(p string) (os.FileInfo, string, error) {
+	info, err := c.backend().Stat(p)
+	if err == nil {
+		return info, p, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+	gz := p + CompressedExt
+	info, gzErr := c.backend().Stat(gz)
+	if gzErr == nil {
+		return info, gz, nil
+	}
+	if !errors.Is(gzErr, os.ErrNotExist) {
+		return nil, "", gzErr
+	}
+	return nil, "", notFound(err)
+}
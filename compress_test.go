@@ -0,0 +1,49 @@
+package interband
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompressedEnvelopes(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+	t.Setenv("INTERBAND_RETENTION_CUSTOM_EVENTS_SECS", "30")
+
+	p, err := Path("custom", "events", "big")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	gz := p + CompressedExt
+	if err := WriteCompressed(gz, "custom", "anything", "s", map[string]any{"diff": "lots of text"}); err != nil {
+		t.Fatalf("compressed write failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(gz)
+	if err != nil || len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
+		t.Fatalf("expected gzip file on disk, err=%v", err)
+	}
+	payload, err := ReadPayload(gz)
+	if err != nil || payload["diff"] != "lots of text" {
+		t.Fatalf("compressed read failed, payload=%v err=%v", payload, err)
+	}
+	if ok, err := Exists("custom", "events", "big"); err != nil || !ok {
+		t.Fatalf("expected compressed key to exist, ok=%v err=%v", ok, err)
+	}
+	envs, _, err := ListChannel("custom", "events")
+	if err != nil || len(envs) != 1 {
+		t.Fatalf("expected compressed envelope listed, envs=%d err=%v", len(envs), err)
+	}
+
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(gz, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+	if err := PruneChannel("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if _, err := os.Stat(gz); !os.IsNotExist(err) {
+		t.Fatalf("expected expired compressed file pruned, stat err=%v", err)
+	}
+}
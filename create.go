@@ -0,0 +1,64 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// WriteIfAbsent writes a message for key only if none is stored yet, plain or
+// compressed, and reports whether it created one. Among concurrent callers,
+// across processes, exactly one creates the file; the rest get created=false
+// and no error. The file appears complete or not at all. It is the primitive
+// for leases and leader election; note that pruning and Delete can free the
+// key again. The backend must implement ExclusiveCreator.
+func WriteIfAbsent(namespace, channel, key, typ, sessionID string, payload map[string]any) (created bool, err error) {
+	return envClient().WriteIfAbsent(namespace, channel, key, typ, sessionID, payload)
+}
+
+func (c *Client) WriteIfAbsent(namespace, channel, key, typ, sessionID string, payload map[string]any) (bool, error) {
+	if err := c.writable(); err != nil {
+		return false, err
+	}
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return false, err
+	}
+	env, err := c.newEnvelope(p, namespace, typ, sessionID, payload)
+	if err != nil {
+		return false, err
+	}
+	env.Key = key
+	return c.createEnvelope(p, env)
+}
+
+// createEnvelope writes env to p unless a message is already stored there,
+// plain or compressed, and reports whether it created the file. env must
+// already be validated.
+func (c *Client) createEnvelope(p string, env Envelope) (bool, error) {
+	creator, ok := c.backend().(ExclusiveCreator)
+	if !ok {
+		return false, errors.New("backend does not support create-only writes")
+	}
+	if _, _, err := c.statKeyFile(p); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	data, err := encodeEnvelope(env)
+	if err != nil {
+		return false, err
+	}
+	if err := checkSize(p, int64(len(data)), c.cfg.MaxPayloadBytes); err != nil {
+		return false, err
+	}
+	if err := creator.CreateExclusive(context.Background(), p, data); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	c.observe(func(o Observer) { o.OnWrite(env.Namespace, env.Type, len(data)) })
+	return true, nil
+}
@@ -0,0 +1,69 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteIfAbsentHasOneWinner(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	const contenders = 16
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := []string{}
+	for i := 0; i < contenders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+			created, err := WriteIfAbsent("custom", "leader", "lease", "anything", id, map[string]any{"holder": id})
+			if err != nil {
+				t.Errorf("write failed: %v", err)
+				return
+			}
+			if created {
+				mu.Lock()
+				winners = append(winners, id)
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(winners) != 1 {
+		t.Fatalf("expected exactly one winner, got %v", winners)
+	}
+
+	p, _ := Path("custom", "leader", "lease")
+	payload, err := ReadPayload(p)
+	if err != nil || payload["holder"] != winners[0] {
+		t.Fatalf("stored lease should be the winner's: %v %v", payload, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(p))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), tempFilePrefix) {
+			t.Fatalf("temp file left behind: %s", entry.Name())
+		}
+	}
+
+	if err := Delete("custom", "leader", "lease"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if created, err := WriteIfAbsent("custom", "leader", "lease", "anything", "next", map[string]any{}); err != nil || !created {
+		t.Fatalf("freed key should be creatable: %v %v", created, err)
+	}
+}
+
+func TestWriteIfAbsentMemBackend(t *testing.T) {
+	c, _ := newMemClient(t)
+	for i, want := range []bool{true, false} {
+		created, err := c.WriteIfAbsent("custom", "leader", "lease", "anything", "s", map[string]any{"n": i})
+		if err != nil || created != want {
+			t.Fatalf("attempt %d: got %v %v, want %v", i, created, err, want)
+		}
+	}
+}
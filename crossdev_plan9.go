@@ -0,0 +1,5 @@
+package interband
+
+// isCrossDevice is always false on Plan 9, which has no EXDEV. A failed
+// rename there is returned as is.
+func isCrossDevice(error) bool { return false }
@@ -0,0 +1,54 @@
+package interband
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// WriteDedup writes payload under a key derived from its content and returns
+// the key. The key is the SHA-256 of the payload's CanonicalJSON, so writing
+// the same logical payload again finds the existing file and does nothing.
+// The type and session are not part of the key. The file is created
+// exclusively, as WriteIfAbsent does, so concurrent writers of one payload
+// leave a single file; the backend must implement ExclusiveCreator.
+func WriteDedup(namespace, channel, typ, sessionID string, payload map[string]any) (string, error) {
+	return envClient().WriteDedup(namespace, channel, typ, sessionID, payload)
+}
+
+func (c *Client) WriteDedup(namespace, channel, typ, sessionID string, payload map[string]any) (string, error) {
+	if err := c.writable(); err != nil {
+		return "", err
+	}
+	if err := Validate(namespace, typ, payload); err != nil {
+		return "", c.observeValidation(err)
+	}
+	key, err := payloadHash(payload)
+	if err != nil {
+		return "", err
+	}
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return "", err
+	}
+	env, err := c.newEnvelope(p, namespace, typ, sessionID, payload)
+	if err != nil {
+		return "", err
+	}
+	env.Key = key
+	if _, err := c.createEnvelope(p, env); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// payloadHash returns the hex SHA-256 of payload's canonical JSON.
+func payloadHash(payload map[string]any) (string, error) {
+	data, err := CanonicalJSON(payload)
+	if err != nil {
+		return "", err
+	}
+	// The trailing newline keeps keys derived before CanonicalJSON existed,
+	// which hashed encoder output, unchanged.
+	sum := sha256.Sum256(append(data, '\n'))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,79 @@
+package interband
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWriteDedup(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	first := map[string]any{"layer": "l", "icon": "i", "text": "t", "ts": "now", "priority": 1, "meta": map[string]any{"b": 2, "a": 1}}
+	// Same logical payload: different insertion order, float instead of int.
+	second := map[string]any{"meta": map[string]any{"a": 1.0, "b": 2.0}, "priority": 1.0, "ts": "now", "text": "t", "icon": "i", "layer": "l"}
+
+	k1, err := WriteDedup("interlock", "coordination", "coordination_signal", "s", first)
+	if err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+	p, _ := Path("interlock", "coordination", k1)
+	before, err := StatKey("interlock", "coordination", k1)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	k2, err := WriteDedup("interlock", "coordination", "coordination_signal", "s", second)
+	if err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+	if k1 != k2 {
+		t.Fatalf("equivalent payloads hashed differently: %s vs %s", k1, k2)
+	}
+	after, _ := StatKey("interlock", "coordination", k1)
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Fatal("duplicate write should not rewrite the file")
+	}
+	if _, err := ReadEnvelope(p); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	first["text"] = "different"
+	k3, err := WriteDedup("interlock", "coordination", "coordination_signal", "s", first)
+	if err != nil || k3 == k1 {
+		t.Fatalf("distinct payload should get a new key: %s %v", k3, err)
+	}
+	envs, _, _ := ListChannel("interlock", "coordination")
+	if len(envs) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(envs))
+	}
+}
+
+func TestWriteDedupConcurrentWritersCreateOneFile(t *testing.T) {
+	c, _ := newMemClient(t)
+	rec := &recordingObserver{}
+	c.cfg.Observer = rec
+
+	payload := map[string]any{"n": 1}
+	var wg sync.WaitGroup
+	keys := make([]string, 8)
+	errs := make([]error, len(keys))
+	for i := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys[i], errs[i] = c.WriteDedup("custom", "events", "anything", "s", payload)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil || keys[i] != keys[0] {
+			t.Fatalf("writer %d: key %q err %v", i, keys[i], err)
+		}
+	}
+	if rec.writes != 1 {
+		t.Fatalf("expected exactly one write, got %d", rec.writes)
+	}
+	envs, _, err := c.ListChannel("custom", "events")
+	if err != nil || len(envs) != 1 {
+		t.Fatalf("expected one message, got %d (%v)", len(envs), err)
+	}
+}
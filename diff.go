@@ -0,0 +1,154 @@
+package interband
+
+import (
+	"bytes"
+	"sort"
+)
+
+// DiffResult reports how two channels differ. Keys are the original keys
+// messages were written under where recorded, and file keys otherwise; each
+// list is sorted.
+type DiffResult struct {
+	// OnlyA and OnlyB list keys stored in just one of the channels.
+	OnlyA []string
+	OnlyB []string
+	// Changed lists keys stored in both whose payloads differ, compared as
+	// CanonicalJSON.
+	Changed []string
+	// Skipped lists files that could not be read. A key whose file was
+	// skipped on either side is left out of the comparison.
+	Skipped []FileError
+}
+
+// Equal reports whether the channels hold the same keys with the same
+// payloads.
+func (d DiffResult) Equal() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && len(d.Changed) == 0
+}
+
+// DiffChannels compares two channels key by key, for reconciling replicas.
+// Keys are matched by file name, so a key stored compressed on one side and
+// plain on the other still matches; where a channel holds both, the newer file
+// counts. Only the directory listings are held in memory, and messages are
+// read one pair at a time. Envelope headers other than the key, such as the
+// timestamp and session, are not compared, and expiry is ignored. Bundle
+// files cannot be compared and are reported in Skipped.
+func DiffChannels(a, b ChannelRef) (DiffResult, error) {
+	return envClient().DiffChannels(a, b)
+}
+
+func (c *Client) DiffChannels(a, b ChannelRef) (DiffResult, error) {
+	var res DiffResult
+	filesA, err := c.diffEntries(a)
+	if err != nil {
+		return res, err
+	}
+	filesB, err := c.diffEntries(b)
+	if err != nil {
+		return res, err
+	}
+
+	// displayKey reads a file's recorded key, falling back to its file key.
+	displayKey := func(entry channelEntry) (string, bool) {
+		hdr, err := c.readHeader(entry.path)
+		if err != nil {
+			res.Skipped = append(res.Skipped, FileError{Path: entry.path, Err: err})
+			return "", false
+		}
+		if hdr.Key != "" {
+			return hdr.Key, true
+		}
+		return entry.key, true
+	}
+	for key, entry := range filesA {
+		other, ok := filesB[key]
+		if !ok {
+			if k, ok := displayKey(entry); ok {
+				res.OnlyA = append(res.OnlyA, k)
+			}
+			continue
+		}
+		envA, errA := c.diffRead(entry.path)
+		envB, errB := c.diffRead(other.path)
+		if errA != nil {
+			res.Skipped = append(res.Skipped, FileError{Path: entry.path, Err: errA})
+		}
+		if errB != nil {
+			res.Skipped = append(res.Skipped, FileError{Path: other.path, Err: errB})
+		}
+		if errA != nil || errB != nil {
+			continue
+		}
+		same, err := samePayload(envA.Payload, envB.Payload)
+		if err != nil {
+			res.Skipped = append(res.Skipped, FileError{Path: entry.path, Err: err})
+			continue
+		}
+		if !same {
+			k := envA.Key
+			if k == "" {
+				k = envB.Key
+			}
+			if k == "" {
+				k = key
+			}
+			res.Changed = append(res.Changed, k)
+		}
+	}
+	for key, entry := range filesB {
+		if _, ok := filesA[key]; ok {
+			continue
+		}
+		if k, ok := displayKey(entry); ok {
+			res.OnlyB = append(res.OnlyB, k)
+		}
+	}
+
+	sort.Strings(res.OnlyA)
+	sort.Strings(res.OnlyB)
+	sort.Strings(res.Changed)
+	sort.Slice(res.Skipped, func(i, j int) bool { return res.Skipped[i].Path < res.Skipped[j].Path })
+	return res, nil
+}
+
+// diffEntries lists a channel's files by file key, keeping the newest file for
+// a key stored both plain and compressed.
+func (c *Client) diffEntries(ref ChannelRef) (map[string]channelEntry, error) {
+	dir, err := c.ChannelDir(ref.Namespace, ref.Channel)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]channelEntry, len(entries))
+	for _, entry := range entries {
+		if prev, ok := out[entry.key]; !ok || entry.modTime.After(prev.modTime) {
+			out[entry.key] = entry
+		}
+	}
+	return out, nil
+}
+
+// diffRead reads and validates an envelope without applying expiry.
+func (c *Client) diffRead(sourcePath string) (Envelope, error) {
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return c.UnmarshalEnvelope(data)
+}
+
+// samePayload compares two payloads by their CanonicalJSON.
+func samePayload(a, b map[string]any) (bool, error) {
+	ca, err := CanonicalJSON(a)
+	if err != nil {
+		return false, err
+	}
+	cb, err := CanonicalJSON(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ca, cb), nil
+}
@@ -0,0 +1,61 @@
+package interband
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestDiffChannels(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	write := func(channel, key string, payload map[string]any) {
+		t.Helper()
+		if err := WriteKey("custom", channel, key, "anything", "s", payload); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	write("a", "same", map[string]any{"x": 1, "y": []any{"z"}})
+	write("b", "same", map[string]any{"y": []any{"z"}, "x": 1.0})
+	write("a", "changed key", map[string]any{"v": 1})
+	write("b", "changed key", map[string]any{"v": 2})
+	write("a", "only-a", map[string]any{})
+	write("b", "only-b", map[string]any{})
+
+	// A path-only write has no recorded key; its file key is reported.
+	p, _ := Path("custom", "b", "legacy")
+	if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	// A compressed copy matches its plain counterpart.
+	pa, _ := Path("custom", "a", "zipped")
+	pb, _ := Path("custom", "b", "zipped")
+	if err := Write(pa, "custom", "anything", "s", map[string]any{"v": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := WriteCompressed(pb+CompressedExt, "custom", "anything", "s", map[string]any{"v": 1}); err != nil {
+		t.Fatalf("compressed write failed: %v", err)
+	}
+	broken, _ := Path("custom", "a", "broken")
+	if err := os.WriteFile(broken, []byte("{"), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	res, err := DiffChannels(ChannelRef{"custom", "a"}, ChannelRef{"custom", "b"})
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if fmt.Sprint(res.OnlyA) != "[only-a]" || fmt.Sprint(res.OnlyB) != "[legacy only-b]" || fmt.Sprint(res.Changed) != "[changed key]" {
+		t.Fatalf("unexpected diff: %+v", res)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0].Path != broken || res.Equal() {
+		t.Fatalf("expected the broken file skipped: %+v", res.Skipped)
+	}
+
+	res, err = DiffChannels(ChannelRef{"custom", "a"}, ChannelRef{"custom", "a"})
+	if err != nil || !res.Equal() {
+		t.Fatalf("a channel should equal itself: %+v %v", res, err)
+	}
+	if _, err := DiffChannels(ChannelRef{"custom", "a"}, ChannelRef{"", "b"}); err == nil {
+		t.Fatal("expected an invalid channel to fail")
+	}
+}
@@ -0,0 +1,78 @@
+package interband
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sources reported by EffectiveConfig besides "env <VARIABLE>".
+const (
+	sourceDefault = "default"
+	sourceConfig  = "config"
+)
+
+// ChannelConfig is the configuration that applies to one channel after every
+// override has been resolved. Sources maps each field name to where its value
+// came from: "env <VARIABLE>", "config" for a Client's Config, or "default".
+type ChannelConfig struct {
+	Namespace        string
+	Channel          string
+	Root             string
+	ProtocolVersion  string
+	RetentionSeconds int
+	MaxFiles         int
+	PruneInterval    time.Duration
+	Sources          map[string]string
+}
+
+// EffectiveConfig resolves the configuration for a channel from the
+// environment, reporting the source of each value, for diagnostics such as a
+// doctor command.
+func EffectiveConfig(namespace, channel string) ChannelConfig {
+	return envClient().EffectiveConfig(namespace, channel)
+}
+
+func (c *Client) EffectiveConfig(namespace, channel string) ChannelConfig {
+	out := ChannelConfig{
+		Namespace:       namespace,
+		Channel:         channel,
+		Root:            c.cfg.Root,
+		ProtocolVersion: c.cfg.ProtocolVersion,
+		PruneInterval:   c.cfg.PruneInterval,
+		Sources:         make(map[string]string),
+	}
+	out.RetentionSeconds, out.Sources["RetentionSeconds"] = c.resolveLimit(namespace, channel, c.cfg.RetentionSeconds, retentionEnvKey, "INTERBAND_RETENTION_SECS", DefaultRetentionSeconds)
+	out.MaxFiles, out.Sources["MaxFiles"] = c.resolveLimit(namespace, channel, c.cfg.MaxFiles, maxFilesEnvKey, "INTERBAND_MAX_FILES", DefaultMaxFiles)
+
+	out.Sources["Root"] = c.envSource("INTERBAND_ROOT", strings.TrimSpace(os.Getenv("INTERBAND_ROOT")) != "")
+	out.Sources["ProtocolVersion"] = c.envSource("INTERBAND_PROTOCOL_VERSION", strings.TrimSpace(os.Getenv("INTERBAND_PROTOCOL_VERSION")) != "")
+	_, set := parseEnvInt("INTERBAND_PRUNE_INTERVAL_SECS")
+	out.Sources["PruneInterval"] = c.envSource("INTERBAND_PRUNE_INTERVAL_SECS", set)
+	return out
+}
+
+// envSource names the source of a client-wide setting.
+func (c *Client) envSource(key string, set bool) string {
+	switch {
+	case !c.env:
+		return sourceConfig
+	case set:
+		return "env " + key
+	default:
+		return sourceDefault
+	}
+}
+
+// String renders the configuration one "field = value (source)" per line.
+func (cc ChannelConfig) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "channel = %s/%s\n", cc.Namespace, cc.Channel)
+	fmt.Fprintf(&b, "root = %s (%s)\n", cc.Root, cc.Sources["Root"])
+	fmt.Fprintf(&b, "protocol_version = %s (%s)\n", cc.ProtocolVersion, cc.Sources["ProtocolVersion"])
+	fmt.Fprintf(&b, "retention_seconds = %d (%s)\n", cc.RetentionSeconds, cc.Sources["RetentionSeconds"])
+	fmt.Fprintf(&b, "max_files = %d (%s)\n", cc.MaxFiles, cc.Sources["MaxFiles"])
+	fmt.Fprintf(&b, "prune_interval = %s (%s)\n", cc.PruneInterval, cc.Sources["PruneInterval"])
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package interband
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEffectiveConfigSources(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("INTERBAND_ROOT", root)
+	t.Setenv("INTERBAND_PROTOCOL_VERSION", "")
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "60")
+	t.Setenv("INTERBAND_RETENTION_SECS", "100")
+	t.Setenv("INTERBAND_RETENTION_CLAVAIN_DISPATCH_SECS", "200")
+	t.Setenv("INTERBAND_MAX_FILES", "")
+
+	got := EffectiveConfig("clavain", "dispatch")
+	if got.Root != root || got.Sources["Root"] != "env INTERBAND_ROOT" {
+		t.Fatalf("unexpected root: %q (%s)", got.Root, got.Sources["Root"])
+	}
+	if got.ProtocolVersion != "1.0.0" || got.Sources["ProtocolVersion"] != "default" {
+		t.Fatalf("unexpected version: %q (%s)", got.ProtocolVersion, got.Sources["ProtocolVersion"])
+	}
+	if got.RetentionSeconds != 200 || got.Sources["RetentionSeconds"] != "env INTERBAND_RETENTION_CLAVAIN_DISPATCH_SECS" {
+		t.Fatalf("unexpected retention: %d (%s)", got.RetentionSeconds, got.Sources["RetentionSeconds"])
+	}
+	if got.MaxFiles != 128 || got.Sources["MaxFiles"] != "default" {
+		t.Fatalf("unexpected max files: %d (%s)", got.MaxFiles, got.Sources["MaxFiles"])
+	}
+	if got.PruneInterval != time.Minute || got.Sources["PruneInterval"] != "env INTERBAND_PRUNE_INTERVAL_SECS" {
+		t.Fatalf("unexpected prune interval: %v (%s)", got.PruneInterval, got.Sources["PruneInterval"])
+	}
+	if other := EffectiveConfig("interphase", "bead"); other.RetentionSeconds != 100 || other.Sources["RetentionSeconds"] != "env INTERBAND_RETENTION_SECS" {
+		t.Fatalf("unexpected global retention: %d (%s)", other.RetentionSeconds, other.Sources["RetentionSeconds"])
+	}
+	if !strings.Contains(got.String(), "retention_seconds = 200 (env INTERBAND_RETENTION_CLAVAIN_DISPATCH_SECS)") {
+		t.Fatalf("unexpected rendering:\n%s", got)
+	}
+
+	cfg := DefaultConfig()
+	cfg.MaxFiles = map[ChannelRef]int{{Namespace: "clavain", Channel: "dispatch"}: 5}
+	cc := NewClient(cfg).EffectiveConfig("clavain", "dispatch")
+	if cc.MaxFiles != 5 || cc.Sources["MaxFiles"] != "config" || cc.Sources["Root"] != "config" || cc.Sources["RetentionSeconds"] != "default" {
+		t.Fatalf("unexpected client config: %+v", cc)
+	}
+}
@@ -0,0 +1,86 @@
+package interband
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrValidation matches every *ValidationError.
+	ErrValidation = errors.New("interband: validation failed")
+	// ErrNotFound reports a missing message file.
+	ErrNotFound = errors.New("interband: not found")
+	// ErrUnsupportedVersion reports an envelope version this reader rejects.
+	ErrUnsupportedVersion = errors.New("interband: unsupported version")
+	// ErrPayloadTooLarge reports an encoded envelope over the configured
+	// MaxPayloadBytes, on write or on read.
+	ErrPayloadTooLarge = errors.New("interband: payload too large")
+	// ErrConflict reports a message that changed underneath an Update.
+	ErrConflict = errors.New("interband: conflicting update")
+	// ErrExpired reports a message whose expires_at has passed, when the
+	// reader honors expiry.
+	ErrExpired = errors.New("interband: expired")
+	// ErrMalformed reports data that does not decode as JSON of the envelope's
+	// shape, such as a truncated file. The decoding error is wrapped too.
+	ErrMalformed = errors.New("interband: malformed envelope")
+	// ErrUnsigned reports an envelope without a signature where one is
+	// required.
+	ErrUnsigned = errors.New("interband: envelope not signed")
+	// ErrSignatureInvalid reports a signature that does not match the
+	// envelope, because it was altered or signed with another key.
+	ErrSignatureInvalid = errors.New("interband: invalid signature")
+	// ErrReadOnly reports a mutating call on a client configured read-only.
+	ErrReadOnly = errors.New("interband: read-only")
+)
+
+// ValidationError reports a payload or envelope that breaks its contract.
+// Namespace and Type are empty for envelope-level failures.
+type ValidationError struct {
+	Namespace string
+	Type      string
+	Field     string
+	Reason    string
+	// Err is the underlying error returned by a registered validator, if any.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Namespace == "" && e.Type == "" {
+		return e.Reason
+	}
+	return e.Namespace + "/" + e.Type + ": " + e.Reason
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+func invalidPayload(namespace, typ, field, format string, args ...any) *ValidationError {
+	return &ValidationError{Namespace: namespace, Type: typ, Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+func invalidEnvelope(field, format string, args ...any) *ValidationError {
+	return &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+// checkSize fails with ErrPayloadTooLarge when size exceeds a positive limit.
+func checkSize(path string, size, limit int64) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %s exceeds %d bytes", ErrPayloadTooLarge, path, limit)
+	}
+	return nil
+}
+
+// notFound wraps a missing-file error so it matches both ErrNotFound and
+// os.ErrNotExist.
+func notFound(err error) error {
+	return fmt.Errorf("%w: %w", ErrNotFound, err)
+}
+
+// malformed wraps a JSON decoding error with ErrMalformed.
+func malformed(err error) error {
+	return fmt.Errorf("%w: %w", ErrMalformed, err)
+}
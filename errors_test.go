@@ -0,0 +1,45 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestStructuredErrors(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	err := ValidatePayload("clavain", "dispatch", map[string]any{"name": "n", "workdir": "w"})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Namespace != "clavain" || verr.Type != "dispatch" || verr.Field != "activity" {
+		t.Fatalf("unexpected validation error: %#v", verr)
+	}
+	if err.Error() != "clavain/dispatch: activity must be a non-empty string" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+
+	p, err := Path("custom", "events", "missing")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	_, err = ReadEnvelope(p)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	err = ValidateEnvelope(Envelope{Version: "2.0.0", Namespace: "n", Type: "t", Timestamp: "2024-01-02T03:04:05Z", Payload: map[string]any{}})
+	if !errors.Is(err, ErrUnsupportedVersion) || errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrUnsupportedVersion only, got %v", err)
+	}
+
+	t.Cleanup(func() { RegisterValidator("acme", "ping", nil) })
+	custom := errors.New("acme/ping: host missing")
+	RegisterValidator("acme", "ping", func(map[string]any) error { return custom })
+	err = ValidatePayload("acme", "ping", map[string]any{})
+	if !errors.Is(err, ErrValidation) || !errors.Is(err, custom) {
+		t.Fatalf("expected registered error wrapped as validation error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package interband
+
+import (
+	"strconv"
+	"time"
+)
+
+// ExpiresAtField is the optional payload field through which a message
+// declares its own lifetime: an RFC 3339 timestamp, or epoch seconds or
+// milliseconds as a number or numeric string. Readers honor it only with
+// Config.HonorExpiry (INTERBAND_HONOR_EXPIRY=1).
+const ExpiresAtField = "expires_at"
+
+// ExpiresAt returns the payload's expires_at time, if it is present and
+// parses.
+func (e Envelope) ExpiresAt() (time.Time, bool) {
+	return parseExpiry(e.Payload[ExpiresAtField])
+}
+
+// Expired reports whether the envelope declares an expiry at or before now.
+func (e Envelope) Expired(now time.Time) bool {
+	at, ok := e.ExpiresAt()
+	return ok && !now.Before(at)
+}
+
+func parseExpiry(v any) (time.Time, bool) {
+	var raw string
+	switch x := v.(type) {
+	case string:
+		raw = x
+	default:
+		f, ok := toFloat64(v)
+		if !ok {
+			return time.Time{}, false
+		}
+		raw = strconv.FormatInt(int64(f), 10)
+	}
+	t, err := parseEnvelopeTime(raw)
+	return t, err == nil
+}
+
+// expired reports whether the client honors expiry and env has expired.
+func (c *Client) expired(env Envelope) bool {
+	return c.cfg.HonorExpiry && env.Expired(c.now())
+}
+
+// markExpired gives entries whose envelope has expired the zero time, so
+// pruning removes them whatever the retention window.
+func (c *Client) markExpired(entries []channelEntry, now time.Time) {
+	for idx := range entries {
+		hdr, err := c.readHeader(entries[idx].path)
+		if err != nil {
+			continue
+		}
+		if at, ok := parseExpiry(hdr.Payload.ExpiresAt); ok && !now.Before(at) {
+			entries[idx].modTime = time.Time{}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func writeExpiring(t *testing.T, c *Client, key string, expiresAt any) string {
+	t.Helper()
+	p, err := c.Path("custom", "leases", key)
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	payload := map[string]any{"holder": key}
+	if expiresAt != nil {
+		payload[ExpiresAtField] = expiresAt
+	}
+	if err := c.Write(p, "custom", "lease", "s", payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return p
+}
+
+func TestExpiresAtParsesTimesAndEpochs(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, v := range []any{at.Format(time.RFC3339), float64(at.Unix()), "1767323045"} {
+		env := Envelope{Payload: map[string]any{ExpiresAtField: v}}
+		got, ok := env.ExpiresAt()
+		if !ok || !got.Equal(at) {
+			t.Fatalf("ExpiresAt(%v) = %v, %v; want %v", v, got, ok, at)
+		}
+		if !env.Expired(at) || env.Expired(at.Add(-time.Second)) {
+			t.Fatalf("Expired(%v) boundary wrong", v)
+		}
+	}
+	if _, ok := (Envelope{Payload: map[string]any{}}).ExpiresAt(); ok {
+		t.Fatal("missing expires_at should not parse")
+	}
+}
+
+func TestHonorExpiryReads(t *testing.T) {
+	c, _ := newMemClient(t)
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	stale := writeExpiring(t, c, "stale", past)
+	writeExpiring(t, c, "live", future)
+	writeExpiring(t, c, "forever", nil)
+
+	// Expiry is opt-in: the expired envelope still round-trips.
+	env, err := c.ReadEnvelope(stale)
+	if err != nil {
+		t.Fatalf("read without HonorExpiry failed: %v", err)
+	}
+	if got, _ := env.ExpiresAt(); got.Format(time.RFC3339) != past {
+		t.Fatalf("expires_at = %v, want %s", got, past)
+	}
+
+	c.cfg.HonorExpiry = true
+	if _, err := c.ReadEnvelope(stale); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+	if _, err := c.ReadEnvelopeStrict(stale); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired from strict read, got %v", err)
+	}
+	var into map[string]any
+	if err := c.ReadPayloadInto(stale, &into); !errors.Is(err, ErrExpired) || into != nil {
+		t.Fatalf("expected ErrExpired from ReadPayloadInto, got %v %v", into, err)
+	}
+	envs, skipped, err := c.ListChannel("custom", "leases")
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("list: %v, skipped %v", err, skipped)
+	}
+	if len(envs) != 2 {
+		t.Fatalf("expected 2 live envelopes, got %d", len(envs))
+	}
+	for _, env := range envs {
+		if env.Payload["holder"] == "stale" {
+			t.Fatal("expired envelope listed")
+		}
+	}
+	got, err := c.Query("custom", "leases", QueryFilter{})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("query: %d envelopes, %v", len(got), err)
+	}
+	it, err := c.NewChannelIterator("custom", "leases")
+	if err != nil {
+		t.Fatalf("iterator: %v", err)
+	}
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 2 || it.Err() != nil {
+		t.Fatalf("iterator saw %d envelopes, err %v", n, it.Err())
+	}
+}
+
+func TestHonorExpiryPrunesRegardlessOfAge(t *testing.T) {
+	c, _ := newMemClient(t)
+	c.cfg.HonorExpiry = true
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	writeExpiring(t, c, "stale", past)
+	writeExpiring(t, c, "live", nil)
+
+	if err := c.PruneChannel("custom", "leases"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	for key, want := range map[string]bool{"stale": false, "live": true} {
+		ok, err := c.Exists("custom", "leases", key)
+		if err != nil || ok != want {
+			t.Fatalf("Exists(%s) = %v, %v; want %v", key, ok, err, want)
+		}
+	}
+}
+
+func TestUpdateReplacesExpiredValue(t *testing.T) {
+	c, _ := newMemClient(t)
+	c.cfg.HonorExpiry = true
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	p := writeExpiring(t, c, "stale", past)
+
+	err := c.Update("custom", "leases", "stale", "lease", "s", func(payload map[string]any) (map[string]any, error) {
+		if len(payload) != 0 {
+			t.Errorf("expired value passed to update: %v", payload)
+		}
+		payload["holder"] = "next"
+		return payload, nil
+	})
+	if err != nil {
+		t.Fatalf("update over expired value failed: %v", err)
+	}
+	env, err := c.ReadEnvelope(p)
+	if err != nil || env.Payload["holder"] != "next" {
+		t.Fatalf("read after update = %v, %v", env.Payload, err)
+	}
+}
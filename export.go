@@ -0,0 +1,113 @@
+package interband
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ExportChannel writes every readable envelope in a channel to w as
+// newline-delimited JSON, in ListChannel order, and returns how many it wrote.
+func ExportChannel(w io.Writer, namespace, channel string) (int, error) {
+	return envClient().ExportChannel(w, namespace, channel)
+}
+
+func (c *Client) ExportChannel(w io.Writer, namespace, channel string) (int, error) {
+	envs, _, err := c.ListChannel(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	for idx, env := range envs {
+		line, err := encodeEnvelope(env)
+		if err != nil {
+			return idx, err
+		}
+		if _, err := w.Write(line); err != nil {
+			return idx, err
+		}
+	}
+	return len(envs), nil
+}
+
+// ImportChannel reads newline-delimited envelopes from r and writes each one
+// verbatim into the channel. An envelope that records its key is stored under
+// that key; others get the channel's next Append sequence keys. Existing
+// messages are never replaced: an envelope whose key is already stored is
+// skipped, so importing the same export twice is harmless. Import holds the
+// channel lock and needs a backend that implements ExclusiveCreator. It stops
+// at the first envelope that fails to decode, validate, or write and returns
+// the count written before it.
+func ImportChannel(r io.Reader, namespace, channel string) (int, error) {
+	return envClient().ImportChannel(r, namespace, channel)
+}
+
+func (c *Client) ImportChannel(r io.Reader, namespace, channel string) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = c.WithChannelLock(namespace, channel, func() error {
+		var seq, lastSeq uint64
+		dec := json.NewDecoder(r)
+		for line := 1; ; line++ {
+			var env Envelope
+			if err := dec.Decode(&env); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if err := validateEnvelope(env, c.writeOptions()); err != nil {
+				return fmt.Errorf("line %d: %w", line, c.observeValidation(err))
+			}
+			if env.Key != "" {
+				p, err := c.Path(namespace, channel, env.Key)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+				created, err := c.createEnvelope(p, env)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+				if created {
+					n++
+				}
+				continue
+			}
+			if seq == 0 {
+				if seq, err = c.nextSeq(dir); err != nil {
+					return err
+				}
+			}
+			// A keyed envelope earlier in the stream may hold a sequence key.
+			for {
+				p, err := c.Path(namespace, channel, fmt.Sprintf("%0*d", SeqKeyWidth, seq))
+				if err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+				created, err := c.createEnvelope(p, env)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+				lastSeq = seq
+				seq++
+				if created {
+					n++
+					break
+				}
+			}
+		}
+		if lastSeq == 0 {
+			return nil
+		}
+		return c.backend().WriteAtomic(context.Background(), seqPath(dir), []byte(strconv.FormatUint(lastSeq, 10)))
+	})
+	return n, err
+}
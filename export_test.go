@@ -0,0 +1,92 @@
+package interband
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	for _, key := range []string{"a", "b", "c"} {
+		p, err := Path("custom", "src", key)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := Write(p, "custom", "anything", "s", map[string]any{"key": key}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := ExportChannel(&buf, "custom", "src")
+	if err != nil || n != 3 {
+		t.Fatalf("export failed, n=%d err=%v", n, err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("expected 3 NDJSON lines, got %d", lines)
+	}
+
+	n, err = ImportChannel(&buf, "custom", "dst")
+	if err != nil || n != 3 {
+		t.Fatalf("import failed, n=%d err=%v", n, err)
+	}
+	src, _, _ := ListChannel("custom", "src")
+	dst, _, _ := ListChannel("custom", "dst")
+	if len(dst) != 3 {
+		t.Fatalf("expected 3 imported envelopes, got %d", len(dst))
+	}
+	for idx := range src {
+		if src[idx].Timestamp != dst[idx].Timestamp || src[idx].Payload["key"] != dst[idx].Payload["key"] {
+			t.Fatalf("envelope %d changed on round trip: %+v vs %+v", idx, src[idx], dst[idx])
+		}
+	}
+
+	n, err = ImportChannel(strings.NewReader(`{"version":"1.0.0"}`+"\n"), "custom", "bad")
+	if err == nil || n != 0 {
+		t.Fatalf("expected invalid envelope to stop import, n=%d err=%v", n, err)
+	}
+}
+
+func TestImportNeverReplacesMessages(t *testing.T) {
+	c, _ := newMemClient(t)
+	if err := c.WriteKey("custom", "src", "named", "anything", "s", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	p, _ := c.Path("custom", "src", "unnamed")
+	if err := c.Write(p, "custom", "anything", "s", map[string]any{"n": 2}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	var export bytes.Buffer
+	if _, err := c.ExportChannel(&export, "custom", "src"); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	// The destination already holds the first sequence key.
+	if _, err := c.Append("custom", "dst", "anything", "s", map[string]any{"n": 0}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	n, err := c.ImportChannel(bytes.NewReader(export.Bytes()), "custom", "dst")
+	if err != nil || n != 2 {
+		t.Fatalf("first import: n=%d err=%v", n, err)
+	}
+	n, err = c.ImportChannel(bytes.NewReader(export.Bytes()), "custom", "dst")
+	if err != nil || n != 1 {
+		t.Fatalf("second import should only add the unkeyed envelope: n=%d err=%v", n, err)
+	}
+
+	got, err := c.ReadChannelMap("custom", "dst")
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	want := map[string]float64{"0000001": 0, "named": 1, "0000002": 2, "0000003": 2}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected keys: %v", got)
+	}
+	for key, n := range want {
+		if got[key].Payload["n"] != n {
+			t.Fatalf("key %s = %v, want n=%v", key, got[key].Payload, n)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Handler serves one channel over HTTP, relative to wherever it is mounted
+// (use http.StripPrefix for a sub-path):
+//
+//	GET  /          every envelope, oldest first, as a JSON array
+//	GET  /?latest=1 the newest envelope
+//	GET  /{key}     the envelope stored for key
+//	POST /          write the envelope in the body under a key derived from
+//	                its payload, as WriteDedup derives it
+//	POST /{key}     write the envelope in the body under key
+//
+// Posted envelopes are validated as WriteEnvelope validates them and must
+// belong to the handler's namespace; their timestamp and version are kept.
+// Successful posts answer 201 with {"key": ...}. Validation failures and
+// malformed bodies answer 400, missing keys 404, and envelopes over
+// MaxPayloadBytes 413.
+func Handler(namespace, channel string) http.Handler {
+	return envClient().Handler(namespace, channel)
+}
+
+func (c *Client) Handler(namespace, channel string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if strings.Contains(key, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			c.serveGet(w, r, namespace, channel, key)
+		case http.MethodPost:
+			c.servePost(w, r, namespace, channel, key)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (c *Client) serveGet(w http.ResponseWriter, r *http.Request, namespace, channel, key string) {
+	switch {
+	case key != "":
+		p, err := c.Path(namespace, channel, key)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		_, actual, err := c.statKeyFile(p)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		env, err := c.ReadEnvelope(actual)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, env)
+	case r.URL.Query().Get("latest") != "":
+		env, err := c.ReadLatest(namespace, channel)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, env)
+	default:
+		envs, _, err := c.ListChannel(namespace, channel)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		if envs == nil {
+			envs = []Envelope{}
+		}
+		writeJSON(w, http.StatusOK, envs)
+	}
+}
+
+func (c *Client) servePost(w http.ResponseWriter, r *http.Request, namespace, channel, key string) {
+	env, err := c.DecodeEnvelope(r.Body)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	if env.Namespace != namespace {
+		writeHTTPError(w, invalidEnvelope("namespace", "namespace %q does not match channel namespace %q", env.Namespace, namespace))
+		return
+	}
+	if key == "" {
+		if key, err = payloadHash(env.Payload); err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+	} else {
+		env.Key = key
+	}
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	if err := c.WriteEnvelope(p, env); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"key": key})
+}
+
+// httpStatus maps an interband error onto an HTTP status code.
+func httpStatus(err error) int {
+	var syntax *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrValidation), errors.Is(err, ErrUnsupportedVersion),
+		errors.Is(err, ErrMalformed), errors.As(err, &syntax), errors.As(err, &typeErr):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeHTTPError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), httpStatus(err))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+}
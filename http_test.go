@@ -0,0 +1,90 @@
+package interband
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	c := NewClient(Config{Root: t.TempDir(), MaxPayloadBytes: 1024})
+	srv := httptest.NewServer(c.Handler("interphase", "bead"))
+	defer srv.Close()
+
+	do := func(method, path, body string) (*http.Response, []byte) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return resp, buf.Bytes()
+	}
+	encode := func(phase string, at time.Time) string {
+		env, err := c.NewEnvelope("interphase", "bead_phase").At(at).
+			Set("id", "iv-1").Set("phase", phase).Set("reason", "r").Set("ts", 1).Build()
+		if err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+		data, _ := json.Marshal(env)
+		return string(data)
+	}
+
+	if resp, body := do("GET", "/", ""); resp.StatusCode != 200 || strings.TrimSpace(string(body)) != "[]" {
+		t.Fatalf("empty list: %d %s", resp.StatusCode, body)
+	}
+	if resp, _ := do("GET", "/?latest=1", ""); resp.StatusCode != 404 {
+		t.Fatalf("latest of empty channel: %d", resp.StatusCode)
+	}
+
+	base := time.Now().Add(-time.Minute)
+	resp, body := do("POST", "/s1", encode("planned", base))
+	if resp.StatusCode != 201 || !strings.Contains(string(body), `"s1"`) {
+		t.Fatalf("post with key: %d %s", resp.StatusCode, body)
+	}
+	resp, body = do("POST", "/", encode("executing", base.Add(time.Second)))
+	var created map[string]string
+	_ = json.Unmarshal(body, &created)
+	if resp.StatusCode != 201 || len(created["key"]) != 64 {
+		t.Fatalf("post with derived key: %d %s", resp.StatusCode, body)
+	}
+
+	resp, body = do("GET", "/s1", "")
+	var env Envelope
+	if resp.StatusCode != 200 || json.Unmarshal(body, &env) != nil || env.Payload["phase"] != "planned" {
+		t.Fatalf("get key: %d %s", resp.StatusCode, body)
+	}
+	resp, body = do("GET", "/?latest=1", "")
+	if resp.StatusCode != 200 || json.Unmarshal(body, &env) != nil || env.Payload["phase"] != "executing" {
+		t.Fatalf("latest: %d %s", resp.StatusCode, body)
+	}
+	var all []Envelope
+	if resp, body = do("GET", "/", ""); resp.StatusCode != 200 || json.Unmarshal(body, &all) != nil || len(all) != 2 {
+		t.Fatalf("list: %d %s", resp.StatusCode, body)
+	}
+
+	cases := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/missing", "", 404},
+		{"POST", "/bad", `{"version":"1.0.0","namespace":"interphase","type":"bead_phase","timestamp":"2024-01-01T00:00:00Z","payload":{}}`, 400},
+		{"POST", "/bad", `{not json`, 400},
+		{"POST", "/other", strings.Replace(encode("planned", base), `"interphase"`, `"clavain"`, 1), 400},
+		{"POST", "/big", `{"payload":"` + strings.Repeat("x", 2048) + `"}`, 413},
+		{"DELETE", "/s1", "", 405},
+		{"GET", "/a/b", "", 404},
+	}
+	for _, tc := range cases {
+		if resp, body := do(tc.method, tc.path, tc.body); resp.StatusCode != tc.want {
+			t.Fatalf("%s %s: got %d, want %d (%s)", tc.method, tc.path, resp.StatusCode, tc.want, body)
+		}
+	}
+}
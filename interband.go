@@ -1,9 +1,14 @@
 package interband
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -21,28 +26,72 @@ type Envelope struct {
 	SessionID string         `json:"session_id"`
 	Timestamp string         `json:"timestamp"`
 	Payload   map[string]any `json:"payload"`
+	// Key is the logical key the message was written under, before Path
+	// sanitized it into a file name. Writers that take a key record it;
+	// envelopes written by path alone, or by the bash helpers, omit it.
+	Key string `json:"key,omitempty"`
+	// Signature is the HMAC WriteSigned or SignEnvelope stored, if any.
+	Signature string `json:"signature,omitempty"`
 }
 
-var allowedPhases = map[string]struct{}{
-	"brainstorm":          {},
-	"brainstorm-reviewed": {},
-	"strategized":         {},
-	"planned":             {},
-	"plan-reviewed":       {},
-	"executing":           {},
-	"shipping":            {},
-	"done":                {},
+// Time parses the envelope timestamp. It accepts RFC 3339 at any fractional
+// precision and Unix epoch seconds or milliseconds. The stored Timestamp is
+// left untouched.
+func (e Envelope) Time() (time.Time, error) {
+	return parseEnvelopeTime(e.Timestamp)
+}
+
+// UnmarshalJSON accepts a timestamp written as a JSON number (epoch seconds or
+// milliseconds) as well as a string, keeping its literal text in Timestamp.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	type plain Envelope
+	var aux struct {
+		plain
+		Timestamp json.RawMessage `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*e = Envelope(aux.plain)
+	ts, err := decodeTimestamp(aux.Timestamp)
+	e.Timestamp = ts
+	return err
+}
+
+// decodeTimestamp returns the text of a JSON timestamp, string or number.
+func decodeTimestamp(raw json.RawMessage) (string, error) {
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return "", nil
+	case raw[0] == '"':
+		var ts string
+		err := json.Unmarshal(raw, &ts)
+		return ts, err
+	default:
+		return string(raw), nil
+	}
+}
+
+func parseEnvelopeTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, nil
+	}
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		// Values this large are milliseconds; seconds would be past year 33000.
+		if n >= 1e12 || n <= -1e12 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", raw)
 }
 
 func Root() string {
 	if root := strings.TrimSpace(os.Getenv("INTERBAND_ROOT")); root != "" {
 		return root
 	}
-	home, err := os.UserHomeDir()
-	if err != nil || strings.TrimSpace(home) == "" {
-		return ".interband"
-	}
-	return filepath.Join(home, ".interband")
+	return defaultRoot()
 }
 
 func ProtocolVersion() string {
@@ -52,6 +101,28 @@ func ProtocolVersion() string {
 	return "1.0.0"
 }
 
+// TimestampLayout returns the time layout used for envelope timestamps.
+// INTERBAND_TIMESTAMP_PRECISION selects "s" (RFC 3339 seconds), "ms", "us",
+// or "ns" (default, RFC 3339 with nanoseconds). Second precision gives
+// identical timestamps to writes within the same second; it matches what the
+// bash helpers write.
+func TimestampLayout() string {
+	return timestampLayout(os.Getenv("INTERBAND_TIMESTAMP_PRECISION"))
+}
+
+func timestampLayout(precision string) string {
+	switch strings.ToLower(strings.TrimSpace(precision)) {
+	case "ms":
+		return "2006-01-02T15:04:05.000Z07:00"
+	case "us":
+		return "2006-01-02T15:04:05.000000Z07:00"
+	case "s":
+		return time.RFC3339
+	default:
+		return time.RFC3339Nano
+	}
+}
+
 func SafeKey(raw string) string {
 	var b strings.Builder
 	for _, r := range raw {
@@ -74,106 +145,417 @@ func SafeKey(raw string) string {
 	return out
 }
 
+// SafeKeyUnicode is SafeKey that also keeps non-ASCII letters and digits, so
+// labels such as "résumé" and "日本語" stay distinct. Separators, whitespace,
+// control characters, and punctuation are still replaced with '_'. The bash
+// helpers only produce SafeKey names, so enable this (Config.UnicodeKeys or
+// INTERBAND_UNICODE_KEYS=1) only for channels written from Go.
+func SafeKeyUnicode(raw string) string {
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r < 0x80:
+			b.WriteString(SafeKey(string(r)))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	out := b.String()
+	if out == "" {
+		return "default"
+	}
+	return out
+}
+
+// SafeKeyPortable is SafeKey that also yields names Windows can create: a
+// stem (the part before the first '.') naming a device such as CON, nul, or
+// COM1 gets a leading '_', and trailing dots become '_'. Enable it with
+// Config.PortableKeys or INTERBAND_PORTABLE_KEYS=1; the bash helpers do not
+// apply it.
+func SafeKeyPortable(raw string) string {
+	return portableKey(SafeKey(raw))
+}
+
+// windowsDeviceNames are the stems Windows reserves in every directory,
+// regardless of case or extension.
+var windowsDeviceNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func portableKey(key string) string {
+	stem, _, _ := strings.Cut(key, ".")
+	if windowsDeviceNames[strings.ToUpper(stem)] {
+		key = "_" + key
+	}
+	trimmed := strings.TrimRight(key, ". ")
+	return trimmed + strings.Repeat("_", len(key)-len(trimmed))
+}
+
+// SafeKeyUnique is SafeKey that appends "-" and a short hash of raw whenever
+// sanitizing changed it, so "a/b", "a b", and "a?b" land in different files.
+// Keys SafeKey already leaves untouched are returned as-is.
+func SafeKeyUnique(raw string) string {
+	return withKeyHash(raw, SafeKey(raw))
+}
+
+// withKeyHash appends a hash of raw to sanitized when the two differ.
+func withKeyHash(raw, sanitized string) string {
+	if sanitized == raw {
+		return sanitized
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return sanitized + "-" + hex.EncodeToString(sum[:4])
+}
+
 func ChannelDir(namespace, channel string) (string, error) {
+	return envClient().ChannelDir(namespace, channel)
+}
+
+func (c *Client) ChannelDir(namespace, channel string) (string, error) {
 	if strings.TrimSpace(namespace) == "" || strings.TrimSpace(channel) == "" {
 		return "", errors.New("namespace and channel are required")
 	}
-	return filepath.Join(Root(), namespace, channel), nil
+	if err := checkSegment("namespace", namespace); err != nil {
+		return "", err
+	}
+	if err := checkSegment("channel", channel); err != nil {
+		return "", err
+	}
+	return c.layout().ChannelDir(c.cfg.Root, namespace, channel), nil
 }
 
 func Path(namespace, channel, key string) (string, error) {
+	return envClient().Path(namespace, channel, key)
+}
+
+func (c *Client) Path(namespace, channel, key string) (string, error) {
 	if strings.TrimSpace(namespace) == "" || strings.TrimSpace(channel) == "" || strings.TrimSpace(key) == "" {
 		return "", errors.New("namespace, channel, and key are required")
 	}
-	return filepath.Join(Root(), namespace, channel, SafeKey(key)+".json"), nil
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return "", err
+	}
+	return c.keyPath(dir, key)
 }
 
-func ValidatePayload(namespace, typ string, payload map[string]any) error {
-	if payload == nil {
-		return errors.New("payload must be an object")
+// keyPath places key's envelope file within the channel directory dir.
+func (c *Client) keyPath(dir, key string) (string, error) {
+	stem := c.safeKey(key)
+	name := stem + ".json"
+	if IsReservedName(name) {
+		return "", invalidEnvelope("key", "key %q uses the reserved prefix %s", key, ReservedPrefix)
+	}
+	// Only reachable with MaxKeyBytes off; the compressed form must fit too.
+	if len(stem) > maxNameBytes-len(".json.gz") {
+		return "", invalidEnvelope("key", "key %q sanitizes to %d bytes, too long for a file name; set MaxKeyBytes (INTERBAND_MAX_KEY_BYTES) to cut long keys", key, len(stem))
 	}
+	return c.layout().KeyPath(dir, name), nil
+}
 
-	switch namespace + ":" + typ {
-	case "interphase:bead_phase":
-		if !isNonEmptyString(payload["id"]) {
-			return errors.New("interphase/bead_phase: id must be a non-empty string")
-		}
-		phase, ok := payload["phase"].(string)
-		if !ok || phase == "" {
-			return errors.New("interphase/bead_phase: phase must be a non-empty string")
-		}
-		if _, ok := allowedPhases[phase]; !ok {
-			return fmt.Errorf("interphase/bead_phase: unknown phase %q", phase)
-		}
-		if v, exists := payload["reason"]; exists && v != nil {
-			if _, ok := v.(string); !ok {
-				return errors.New("interphase/bead_phase: reason must be a string")
-			}
-		}
-		if !isNumber(payload["ts"]) {
-			return errors.New("interphase/bead_phase: ts must be numeric")
-		}
-	case "clavain:dispatch":
-		for _, key := range []string{"name", "workdir", "activity"} {
-			if !isNonEmptyString(payload[key]) {
-				return fmt.Errorf("clavain/dispatch: %s must be a non-empty string", key)
-			}
+// checkSegment rejects a namespace or channel that could resolve outside its
+// parent directory or be hidden from discovery: one containing a path
+// separator or NUL, or starting with '.', which covers "." and "..".
+// Absolute paths are caught by the separator check.
+func checkSegment(field, name string) error {
+	if strings.HasPrefix(name, ".") || strings.ContainsAny(name, "/\\\x00") {
+		return invalidEnvelope(field, "%s %q must be a single path segment not starting with '.'", field, name)
+	}
+	return nil
+}
+
+// checkName rejects an envelope namespace or type outside the safe character
+// set (ASCII letters, digits, '-', '_', and '.'), and a namespace that
+// checkSegment would refuse as a directory, so any envelope that validates
+// can be written back under a path derived from it.
+func checkName(field, name string) error {
+	for _, r := range name {
+		if r == '-' || r == '_' || r == '.' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			continue
 		}
-		for _, key := range []string{"started", "turns", "commands", "messages"} {
-			if !isNonNegativeNumber(payload[key]) {
-				return fmt.Errorf("clavain/dispatch: %s must be a non-negative number", key)
-			}
+		return invalidEnvelope(field, "%s %q may only contain ASCII letters, digits, '-', '_', and '.'", field, name)
+	}
+	if field == "namespace" {
+		return checkSegment(field, name)
+	}
+	return nil
+}
+
+func ValidatePayload(namespace, typ string, payload map[string]any) error {
+	_, err := ValidatePayloadResult(namespace, typ, payload)
+	return err
+}
+
+// ValidatePayloadResult validates payload like ValidatePayload and also reports
+// whether any type-specific rules ran. Unknown namespace/type pairs return
+// validated=false with a nil error.
+func ValidatePayloadResult(namespace, typ string, payload map[string]any) (validated bool, err error) {
+	return validatePayload(namespace, typ, payload, false)
+}
+
+// validatePayload applies the type-specific rules. With lenientPhases set, an
+// unknown bead phase is logged instead of rejected so older readers can still
+// surface messages that use newer phases.
+func validatePayload(namespace, typ string, payload map[string]any, lenientPhases bool) (bool, error) {
+	if payload == nil {
+		return false, invalidEnvelope("payload", "payload must be an object")
+	}
+	fn, schema, found := schemaValidator(namespace, typ, payload)
+	if found && fn == nil {
+		return true, invalidPayload(namespace, typ, SchemaField, "unknown schema version %q", schema)
+	}
+	if !found {
+		fn, found = registeredValidator(namespace, typ)
+	}
+	if !found {
+		nsFn, known := namespaceValidator(namespace, typ)
+		if !known {
+			return true, invalidPayload(namespace, typ, "", "type is not registered for namespace %s", namespace)
 		}
-	case "interlock:coordination_signal":
-		for _, key := range []string{"layer", "icon", "text", "ts"} {
-			if !isNonEmptyString(payload[key]) {
-				return fmt.Errorf("interlock/coordination_signal: %s must be a non-empty string", key)
+		fn, found = nsFn, nsFn != nil
+	}
+	if found {
+		if err := fn(payload); err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				return true, err
 			}
+			return true, &ValidationError{Namespace: namespace, Type: typ, Reason: err.Error(), Err: err}
 		}
-		if !isNonNegativeNumber(payload["priority"]) {
-			return errors.New("interlock/coordination_signal: priority must be a non-negative number")
+		return true, nil
+	}
+
+	spec, ok := lookupSpec(namespace, typ)
+	if !ok {
+		return false, nil
+	}
+	if err := spec.validate(payload, lenientPhases); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// ValidateEnvelope checks env as WriteEnvelope would. Options such as
+// RequireUTC tighten the checks.
+func ValidateEnvelope(env Envelope, opts ...ValidateOption) error {
+	var o validateOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
 		}
 	}
+	return validateEnvelope(env, o)
+}
 
-	return nil
+// Validate checks a would-be Write without touching the filesystem: the
+// namespace and type must be set and the payload must pass validation.
+func Validate(namespace, typ string, payload map[string]any) error {
+	if strings.TrimSpace(namespace) == "" || strings.TrimSpace(typ) == "" {
+		return errors.New("namespace and type are required")
+	}
+	if err := checkName("namespace", namespace); err != nil {
+		return err
+	}
+	if err := checkName("type", typ); err != nil {
+		return err
+	}
+	return ValidatePayload(namespace, typ, payload)
 }
 
-func ValidateEnvelope(env Envelope) error {
-	if !strings.HasPrefix(env.Version, "1.") {
-		return fmt.Errorf("unsupported version %q", env.Version)
+// ValidateEnvelopeBytes decodes an encoded envelope, such as one received over
+// a socket, and validates it as WriteEnvelope would. Undecodable input fails
+// with a *ValidationError.
+func ValidateEnvelopeBytes(data []byte) error {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return invalidEnvelope("", "malformed envelope: %v", err)
+	}
+	return ValidateEnvelope(env)
+}
+
+// validateOptions relaxes or tightens envelope validation for readers.
+type validateOptions struct {
+	lenientPhases bool
+	strictVersion bool
+	requireUTC    bool
+}
+
+func validateEnvelope(env Envelope, opts validateOptions) error {
+	if err := validateHeader(env, opts); err != nil {
+		return err
+	}
+	if env.Payload == nil {
+		return invalidEnvelope("payload", "payload must be an object")
+	}
+	_, err := validatePayload(env.Namespace, env.Type, env.Payload, opts.lenientPhases)
+	return err
+}
+
+// validateHeader checks the envelope fields other than the payload.
+func validateHeader(env Envelope, opts validateOptions) error {
+	if err := CheckVersion(env.Version, opts.strictVersion); err != nil {
+		return err
 	}
 	if strings.TrimSpace(env.Namespace) == "" {
-		return errors.New("namespace is required")
+		return invalidEnvelope("namespace", "namespace is required")
 	}
 	if strings.TrimSpace(env.Type) == "" {
-		return errors.New("type is required")
+		return invalidEnvelope("type", "type is required")
+	}
+	if err := checkName("namespace", env.Namespace); err != nil {
+		return err
+	}
+	if err := checkName("type", env.Type); err != nil {
+		return err
 	}
 	if strings.TrimSpace(env.Timestamp) == "" {
-		return errors.New("timestamp is required")
+		return invalidEnvelope("timestamp", "timestamp is required")
 	}
-	if env.Payload == nil {
-		return errors.New("payload must be an object")
+	if _, err := env.Time(); err != nil {
+		return invalidEnvelope("timestamp", "%v", err)
+	}
+	if opts.requireUTC {
+		return checkUTC(env.Timestamp)
+	}
+	return nil
+}
+
+// Write stamps a new envelope with the protocol version, the current time,
+// and sessionID, validates it, and writes it atomically to targetPath. Options
+// such as WithTimestamp override what is stamped.
+func Write(targetPath, namespace, typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	return envClient().Write(targetPath, namespace, typ, sessionID, payload, opts...)
+}
+
+func (c *Client) Write(targetPath, namespace, typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	return c.WriteContext(context.Background(), targetPath, namespace, typ, sessionID, payload, opts...)
+}
+
+// WriteContext is Write with cancellation. The context is checked before each
+// filesystem step, so an expired deadline aborts before the rename and the
+// temp file is removed; a syscall already in progress is not interrupted.
+func WriteContext(ctx context.Context, targetPath, namespace, typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	return envClient().WriteContext(ctx, targetPath, namespace, typ, sessionID, payload, opts...)
+}
+
+func (c *Client) WriteContext(ctx context.Context, targetPath, namespace, typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	env, err := c.newEnvelopeWith(targetPath, namespace, typ, sessionID, payload, opts)
+	if err != nil {
+		return err
 	}
-	return ValidatePayload(env.Namespace, env.Type, env.Payload)
+	return c.writeEnvelope(ctx, targetPath, env)
+}
+
+// newEnvelope checks Write's arguments and stamps a new envelope.
+func (c *Client) newEnvelope(targetPath, namespace, typ, sessionID string, payload map[string]any) (Envelope, error) {
+	if strings.TrimSpace(targetPath) == "" {
+		return Envelope{}, errors.New("target path is required")
+	}
+	payload = NormalizePayload(payload)
+	if err := Validate(namespace, typ, payload); err != nil {
+		return Envelope{}, c.observeValidation(err)
+	}
+	return Envelope{
+		Version:   c.cfg.ProtocolVersion,
+		Namespace: namespace,
+		Type:      typ,
+		SessionID: c.sessionOrDefault(namespace, sessionID),
+		Timestamp: c.now().UTC().Format(timestampLayout(c.cfg.TimestampPrecision)),
+		Payload:   payload,
+	}, nil
 }
 
-func Write(targetPath, namespace, typ, sessionID string, payload map[string]any) error {
+// WriteEnvelope validates env as given, including its version and timestamp,
+// and writes it atomically to targetPath. Unlike Write it does not stamp the
+// current time or protocol version, so captured envelopes can be replayed
+// verbatim.
+func WriteEnvelope(targetPath string, env Envelope) error {
+	return envClient().WriteEnvelope(targetPath, env)
+}
+
+func (c *Client) WriteEnvelope(targetPath string, env Envelope) error {
+	return c.writeEnvelope(context.Background(), targetPath, env)
+}
+
+func (c *Client) writeEnvelope(ctx context.Context, targetPath string, env Envelope) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
 	if strings.TrimSpace(targetPath) == "" {
 		return errors.New("target path is required")
 	}
-	if strings.TrimSpace(namespace) == "" || strings.TrimSpace(typ) == "" {
-		return errors.New("namespace and type are required")
+	if err := validateEnvelope(env, c.writeOptions()); err != nil {
+		return c.observeValidation(err)
+	}
+	if err := c.checkPhaseTransition(targetPath, env); err != nil {
+		return c.observeValidation(err)
+	}
+	data, err := encodeEnvelope(env)
+	if err != nil {
+		return err
 	}
-	if err := ValidatePayload(namespace, typ, payload); err != nil {
+	if err := checkSize(targetPath, int64(len(data)), c.cfg.MaxPayloadBytes); err != nil {
 		return err
 	}
+	if err := c.backend().WriteAtomic(ctx, targetPath, data); err != nil {
+		return err
+	}
+	c.observe(func(o Observer) { o.OnWrite(env.Namespace, env.Type, len(data)) })
+	return nil
+}
 
-	dir := filepath.Dir(targetPath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+func encodeEnvelope(env Envelope) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(env); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFileAtomic writes data to a temp file beside targetPath and renames it
+// into place so readers never observe a partial file. The temp file and the
+// parent directory are fsynced so the result survives a crash after rename.
+// Non-zero modes are applied with chmod to the temp file and to any
+// directories created, so the umask does not narrow them.
+func writeFileAtomic(ctx context.Context, targetPath string, data []byte, fileMode, dirMode os.FileMode) error {
+	return publishFile(ctx, targetPath, data, fileMode, dirMode, false)
+}
+
+// createFileExclusive is writeFileAtomic that fails with an error matching
+// fs.ErrExist instead of replacing an existing targetPath. The complete temp
+// file is hard-linked into place, so readers never see a partial file and
+// exactly one of several racing creators succeeds.
+func createFileExclusive(ctx context.Context, targetPath string, data []byte, fileMode, dirMode os.FileMode) error {
+	return publishFile(ctx, targetPath, data, fileMode, dirMode, true)
+}
+
+// publishFile writes data to a synced temp file beside targetPath, then
+// renames it over targetPath or, when exclusive, links it there.
+func publishFile(ctx context.Context, targetPath string, data []byte, fileMode, dirMode os.FileMode, exclusive bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := mkdirAllMode(filepath.Dir(targetPath), dirMode); err != nil {
 		return err
 	}
+	return publishInDir(ctx, targetPath, data, fileMode, exclusive)
+}
 
-	tmpFile, err := os.CreateTemp(dir, ".interband-tmp.*")
+// publishInDir is publishFile for a parent directory that already exists.
+func publishInDir(ctx context.Context, targetPath string, data []byte, fileMode os.FileMode, exclusive bool) error {
+	dir := filepath.Dir(targetPath)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(dir, tempFilePrefix+"*")
 	if err != nil {
 		return err
 	}
@@ -185,179 +567,666 @@ func Write(targetPath, namespace, typ, sessionID string, payload map[string]any)
 		}
 	}()
 
-	env := Envelope{
-		Version:   ProtocolVersion(),
-		Namespace: namespace,
-		Type:      typ,
-		SessionID: sessionID,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Payload:   payload,
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return err
 	}
-
-	enc := json.NewEncoder(tmpFile)
-	enc.SetEscapeHTML(false)
-	if err := enc.Encode(env); err != nil {
+	if err := ctx.Err(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if fileMode != 0 {
+		if err := tmpFile.Chmod(fileMode); err != nil {
+			_ = tmpFile.Close()
+			return err
+		}
+	}
+	if err := tmpFile.Sync(); err != nil {
 		_ = tmpFile.Close()
 		return err
 	}
 	if err := tmpFile.Close(); err != nil {
 		return err
 	}
-	if err := os.Rename(tmpPath, targetPath); err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	cleanup = false
+	if exclusive {
+		// The temp name is removed by the deferred cleanup; the link stays.
+		if err := os.Link(tmpPath, targetPath); err != nil {
+			return err
+		}
+	} else {
+		if err := os.Rename(tmpPath, targetPath); err != nil {
+			return err
+		}
+		cleanup = false
+	}
+	return syncDir(dir)
+}
+
+// mkdirAllMode creates dir and any missing parents. With a non-zero mode each
+// directory it creates is chmodded to mode, which is how setgid and
+// group-write bits survive the umask; existing directories are left alone.
+func mkdirAllMode(dir string, mode os.FileMode) error {
+	if mode == 0 {
+		return os.MkdirAll(dir, 0o755)
+	}
+	var missing []string
+	for p := dir; ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil || !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		missing = append(missing, p)
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, mode.Perm()); err != nil {
+		return err
+	}
+	for idx := len(missing) - 1; idx >= 0; idx-- {
+		if err := os.Chmod(missing[idx], mode); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func ReadEnvelope(sourcePath string) (Envelope, error) {
+	return envClient().ReadEnvelope(sourcePath)
+}
+
+func (c *Client) ReadEnvelope(sourcePath string) (Envelope, error) {
+	return c.ReadEnvelopeContext(context.Background(), sourcePath)
+}
+
+// ReadEnvelopeContext is ReadEnvelope that fails fast once ctx is done.
+func ReadEnvelopeContext(ctx context.Context, sourcePath string) (Envelope, error) {
+	return envClient().ReadEnvelopeContext(ctx, sourcePath)
+}
+
+func (c *Client) ReadEnvelopeContext(ctx context.Context, sourcePath string) (Envelope, error) {
 	if strings.TrimSpace(sourcePath) == "" {
 		return Envelope{}, errors.New("source path is required")
 	}
-	data, err := os.ReadFile(sourcePath)
+	if err := ctx.Err(); err != nil {
+		return Envelope{}, err
+	}
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return Envelope{}, err
+	}
+	env, err := c.UnmarshalEnvelope(data)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if err := c.finishRead(sourcePath, env, len(data)); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+// finishRead is the step every envelope read shares after decoding and
+// validation: it refuses expired envelopes and reports the read.
+func (c *Client) finishRead(sourcePath string, env Envelope, size int) error {
+	if c.expired(env) {
+		return fmt.Errorf("%w: %s", ErrExpired, sourcePath)
+	}
+	c.observe(func(o Observer) { o.OnRead(env.Namespace, env.Type, size) })
+	return nil
+}
+
+// UnmarshalEnvelope parses and validates an encoded envelope as ReadEnvelope
+// does, without touching the filesystem. Unlike ValidateEnvelopeBytes it
+// applies the reader's leniency, such as tolerating unknown bead phases.
+func UnmarshalEnvelope(data []byte) (Envelope, error) {
+	return envClient().UnmarshalEnvelope(data)
+}
+
+func (c *Client) UnmarshalEnvelope(data []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, malformed(err)
+	}
+	if err := validateEnvelope(env, c.readOptions()); err != nil {
+		return Envelope{}, c.observeValidation(err)
+	}
+	return env, nil
+}
+
+// DecodeEnvelope reads one envelope from r, such as a pipe or an HTTP body,
+// and parses it with UnmarshalEnvelope. Gzip-compressed input is accepted,
+// and input over MaxPayloadBytes fails with ErrPayloadTooLarge.
+func DecodeEnvelope(r io.Reader) (Envelope, error) {
+	return envClient().DecodeEnvelope(r)
+}
+
+func (c *Client) DecodeEnvelope(r io.Reader) (Envelope, error) {
+	data, err := readLimited(r, "envelope", c.cfg.MaxPayloadBytes)
 	if err != nil {
 		return Envelope{}, err
 	}
+	if data, err = maybeGunzip(data, "envelope", c.cfg.MaxPayloadBytes); err != nil {
+		return Envelope{}, err
+	}
+	return c.UnmarshalEnvelope(data)
+}
 
+// ReadEnvelopeStrict is ReadEnvelope that also rejects wrapper keys outside
+// the envelope schema, so a misspelled field such as "timestmap" fails as a
+// *ValidationError naming it instead of loading as an empty value. Payload
+// contents stay freeform.
+func ReadEnvelopeStrict(sourcePath string) (Envelope, error) {
+	return envClient().ReadEnvelopeStrict(sourcePath)
+}
+
+func (c *Client) ReadEnvelopeStrict(sourcePath string) (Envelope, error) {
+	if strings.TrimSpace(sourcePath) == "" {
+		return Envelope{}, errors.New("source path is required")
+	}
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if err := checkEnvelopeKeys(data); err != nil {
+		return Envelope{}, err
+	}
 	var env Envelope
 	if err := json.Unmarshal(data, &env); err != nil {
 		return Envelope{}, err
 	}
-	if err := ValidateEnvelope(env); err != nil {
+	if err := validateEnvelope(env, c.readOptions()); err != nil {
+		return Envelope{}, err
+	}
+	if err := c.finishRead(sourcePath, env, len(data)); err != nil {
 		return Envelope{}, err
 	}
 	return env, nil
 }
 
+var envelopeKeys = map[string]bool{
+	"version": true, "namespace": true, "type": true,
+	"session_id": true, "timestamp": true, "payload": true, "key": true,
+	"signature": true,
+}
+
+// checkEnvelopeKeys fails on the first wrapper key, in sorted order, that is
+// not an exact envelope field name.
+func checkEnvelopeKeys(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !envelopeKeys[k] {
+			return invalidEnvelope(k, "unknown envelope field %q", k)
+		}
+	}
+	return nil
+}
+
 func ReadPayload(sourcePath string) (map[string]any, error) {
-	env, err := ReadEnvelope(sourcePath)
+	return envClient().ReadPayload(sourcePath)
+}
+
+func (c *Client) ReadPayload(sourcePath string) (map[string]any, error) {
+	env, err := c.ReadEnvelope(sourcePath)
 	if err != nil {
 		return nil, err
 	}
 	return env.Payload, nil
 }
 
+// ReadPayloadInto validates the envelope at sourcePath like ReadEnvelope, then
+// decodes its payload JSON directly into v, which must be a pointer. Struct
+// tags and type checks apply as in json.Unmarshal, and numbers landing in
+// interface values decode as json.Number rather than float64. Nothing is
+// decoded into v if the envelope fails validation.
+func ReadPayloadInto(sourcePath string, v any) error {
+	return envClient().ReadPayloadInto(sourcePath, v)
+}
+
+func (c *Client) ReadPayloadInto(sourcePath string, v any) error {
+	if strings.TrimSpace(sourcePath) == "" {
+		return errors.New("source path is required")
+	}
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
+	}
+	if err := validateEnvelope(env, c.readOptions()); err != nil {
+		return err
+	}
+	if err := c.finishRead(sourcePath, env, len(data)); err != nil {
+		return err
+	}
+	var raw struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw.Payload))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func (c *Client) readOptions() validateOptions {
+	return validateOptions{lenientPhases: !c.cfg.StrictReads, strictVersion: c.cfg.StrictVersion, requireUTC: c.cfg.RequireUTC}
+}
+
+// writeOptions is the validation applied to envelopes written as given.
+func (c *Client) writeOptions() validateOptions {
+	return validateOptions{requireUTC: c.cfg.RequireUTC}
+}
+
+// strictReads reports whether readers should reject unknown bead phases
+// instead of logging them. Writers always reject them.
+func strictReads() bool {
+	return envFlag("INTERBAND_STRICT_READS")
+}
+
+func envFlag(name string) bool {
+	v, ok := parseEnvInt(name)
+	return ok && v != 0
+}
+
+// ChannelDefault is the built-in retention policy for one known channel.
+type ChannelDefault struct {
+	Namespace        string
+	Channel          string
+	RetentionSeconds int
+	MaxFiles         int
+}
+
+// channelDefaults mirrors interband_default_retention_secs and
+// interband_default_max_files in lib/interband.sh; keep them in step.
+var channelDefaults = []ChannelDefault{
+	{Namespace: "clavain", Channel: "dispatch", RetentionSeconds: 21600, MaxFiles: 128},       // 6h
+	{Namespace: "interlock", Channel: "coordination", RetentionSeconds: 43200, MaxFiles: 256}, // 12h
+	{Namespace: "interphase", Channel: "bead", RetentionSeconds: 86400, MaxFiles: 256},        // 24h
+	{Namespace: "intercheck", Channel: "pressure", RetentionSeconds: 3600, MaxFiles: 64},      // 1h, per-session
+	{Namespace: "interstat", Channel: "budget", RetentionSeconds: 21600, MaxFiles: 64},        // 6h
+	{Namespace: "intercheck", Channel: "checkpoint", RetentionSeconds: 3600, MaxFiles: 32},    // 1h
+}
+
+// Limits for channels without a built-in default.
+const (
+	fallbackRetentionSeconds = 86400
+	fallbackMaxFiles         = 256
+)
+
+// KnownChannels returns a copy of the built-in channel defaults.
+func KnownChannels() []ChannelDefault {
+	return append([]ChannelDefault(nil), channelDefaults...)
+}
+
+// DefaultRetentionSeconds returns the retention the channel's registered
+// namespace gives it, or the package fallback.
 func DefaultRetentionSeconds(namespace, channel string) int {
-	switch namespace + ":" + channel {
-	case "clavain:dispatch":
-		return 21600 // 6h
-	case "interlock:coordination":
-		return 43200 // 12h
-	case "interphase:bead":
-		return 86400 // 24h
-	default:
-		return 86400
+	if d, ok := channelDefault(namespace, channel); ok && d.RetentionSeconds > 0 {
+		return d.RetentionSeconds
 	}
+	return fallbackRetentionSeconds
 }
 
+// DefaultMaxFiles returns the file cap the channel's registered namespace
+// gives it, or the package fallback.
 func DefaultMaxFiles(namespace, channel string) int {
-	switch namespace + ":" + channel {
-	case "clavain:dispatch":
-		return 128
-	case "interlock:coordination":
-		return 256
-	case "interphase:bead":
-		return 256
-	default:
-		return 256
+	if d, ok := channelDefault(namespace, channel); ok && d.MaxFiles > 0 {
+		return d.MaxFiles
 	}
+	return fallbackMaxFiles
 }
 
 func RetentionSeconds(namespace, channel string) int {
-	if v, ok := parseEnvInt(retentionEnvKey(namespace, channel)); ok {
-		return v
-	}
-	if v, ok := parseEnvInt("INTERBAND_RETENTION_SECS"); ok {
-		return v
-	}
-	return DefaultRetentionSeconds(namespace, channel)
+	return envClient().RetentionSeconds(namespace, channel)
+}
+
+func (c *Client) RetentionSeconds(namespace, channel string) int {
+	v, _ := c.resolveLimit(namespace, channel, c.cfg.RetentionSeconds, retentionEnvKey, "INTERBAND_RETENTION_SECS", DefaultRetentionSeconds)
+	return v
 }
 
 func MaxFiles(namespace, channel string) int {
-	if v, ok := parseEnvInt(maxFilesEnvKey(namespace, channel)); ok {
-		return v
+	return envClient().MaxFiles(namespace, channel)
+}
+
+func (c *Client) MaxFiles(namespace, channel string) int {
+	v, _ := c.resolveLimit(namespace, channel, c.cfg.MaxFiles, maxFilesEnvKey, "INTERBAND_MAX_FILES", DefaultMaxFiles)
+	return v
+}
+
+// resolveLimit applies the per-channel limit precedence: the Config map, then
+// (for environment-backed clients) the per-channel and global variables, then
+// the built-in default. It also names the source it used.
+func (c *Client) resolveLimit(namespace, channel string, cfg map[ChannelRef]int, channelKey func(string, string) string, globalKey string, fallback func(string, string) int) (int, string) {
+	if v, ok := cfg[ChannelRef{namespace, channel}]; ok {
+		return v, sourceConfig
 	}
-	if v, ok := parseEnvInt("INTERBAND_MAX_FILES"); ok {
-		return v
+	if c.env {
+		key := channelKey(namespace, channel)
+		if v, ok := parseEnvInt(key); ok {
+			return v, "env " + key
+		}
+		if v, ok := parseEnvInt(globalKey); ok {
+			return v, "env " + globalKey
+		}
 	}
-	return DefaultMaxFiles(namespace, channel)
+	return fallback(namespace, channel), sourceDefault
 }
 
 func PruneChannel(namespace, channel string) error {
-	dir, err := ChannelDir(namespace, channel)
+	return envClient().PruneChannel(namespace, channel)
+}
+
+func (c *Client) PruneChannel(namespace, channel string) error {
+	_, err := c.PruneChannelStats(namespace, channel)
+	return err
+}
+
+// PruneChannelStats prunes like PruneChannel and reports what it removed.
+// Individual removal failures are not errors; files that could not be
+// removed are counted as remaining. Pruning holds the channel lock, so it
+// must not be called from inside WithChannelLock for the same channel.
+func PruneChannelStats(namespace, channel string) (PruneStats, error) {
+	return envClient().PruneChannelStats(namespace, channel)
+}
+
+func (c *Client) PruneChannelStats(namespace, channel string) (PruneStats, error) {
+	var stats PruneStats
+	if err := c.writable(); err != nil {
+		return stats, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
 	if err != nil {
-		return err
+		return stats, err
+	}
+	b := c.backend()
+	if _, err := b.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return stats, nil
 	}
-	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+
+	// Hold the channel lock from the stamp check through removal, so
+	// concurrent pruners run one at a time and each later one either sees the
+	// fresh stamp and skips or selects from what the previous one left.
+	err = c.WithChannelLock(namespace, channel, func() error {
+		stats = c.pruneLocked(namespace, channel, dir)
 		return nil
+	})
+	if err == nil && !stats.Skipped {
+		c.observe(func(o Observer) { o.OnPrune(namespace, channel, stats.Removed()) })
 	}
+	return stats, err
+}
 
-	now := time.Now()
-	pruneInterval := 300
-	if v, ok := parseEnvInt("INTERBAND_PRUNE_INTERVAL_SECS"); ok {
-		pruneInterval = v
+// pruneLocked does PruneChannelStats' work; the channel lock must be held.
+func (c *Client) pruneLocked(namespace, channel, dir string) PruneStats {
+	var stats PruneStats
+	b := c.backend()
+	now := c.now()
+	if !c.pruneDue(dir, now) {
+		stats.Skipped = true
+		return stats
 	}
-	if pruneInterval < 0 {
-		pruneInterval = 0
+	stamp := func() { _ = b.WriteAtomic(context.Background(), pruneStampPath(dir), c.stampContent(now)) }
+
+	entries, protected, err := c.pruneEntries(dir, now)
+	if err != nil {
+		stamp()
+		return stats
 	}
 
-	stamp := filepath.Join(dir, ".interband-prune.stamp")
-	if info, err := os.Stat(stamp); err == nil {
-		if now.Sub(info.ModTime()) < time.Duration(pruneInterval)*time.Second {
-			return nil
+	// Each removal attempt spends the budget, successful or not.
+	budget := pruneBudget{limit: c.cfg.MaxPruneRemovals}
+	expired, files := splitExpired(entries, c.retention(namespace, channel), now)
+	for idx, entry := range expired {
+		if !budget.spend() {
+			stats.Partial = true
+			files = append(files, expired[idx:]...)
+			break
 		}
+		if b.Remove(entry.path) == nil {
+			stats.Expired++
+			continue
+		}
+		files = append(files, entry)
 	}
-	_ = os.WriteFile(stamp, []byte{}, 0o644)
 
-	retention := time.Duration(RetentionSeconds(namespace, channel)) * time.Second
-	if retention < 0 {
-		retention = 0
+	stats.Remaining = len(files) + protected
+	if !stats.Partial {
+		for _, entry := range overflowEntries(files, c.MaxFiles(namespace, channel), protected) {
+			if !budget.spend() {
+				stats.Partial = true
+				break
+			}
+			if b.Remove(entry.path) == nil {
+				stats.Overflow++
+				stats.Remaining--
+			}
+		}
+	}
+	// A partial pass leaves the stamp alone, so the next prune is due at
+	// once and carries on with the backlog.
+	if !stats.Partial {
+		stamp()
+	}
+	return stats
+}
+
+// pruneBudget caps the removals one prune pass attempts at MaxPruneRemovals.
+type pruneBudget struct {
+	limit int // zero or less means no cap
+	used  int
+}
+
+// spend records a removal attempt, reporting false once the budget is gone.
+func (p *pruneBudget) spend() bool {
+	if p.limit > 0 && p.used >= p.limit {
+		return false
 	}
+	p.used++
+	return true
+}
 
-	type fileInfo struct {
-		path    string
-		modTime time.Time
+// ShouldPrune reports whether PruneChannel would do work now: the channel
+// exists and the prune interval has elapsed since the last prune stamp. Use it
+// to skip the directory scan cheaply when pruning opportunistically.
+func ShouldPrune(namespace, channel string) (bool, error) {
+	return envClient().ShouldPrune(namespace, channel)
+}
+
+func (c *Client) ShouldPrune(namespace, channel string) (bool, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.backend().Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return false, nil
 	}
+	return c.pruneDue(dir, c.now()), nil
+}
 
-	entries, err := os.ReadDir(dir)
+// pruneDue reports whether the prune interval has elapsed since dir's prune
+// stamp was last written. A missing or unreadable stamp is always due.
+func (c *Client) pruneDue(dir string, now time.Time) bool {
+	pruneInterval := c.cfg.PruneInterval
+	if pruneInterval < 0 {
+		pruneInterval = 0
+	}
+	stamp := pruneStampPath(dir)
+	info, err := c.backend().Stat(stamp)
 	if err != nil {
+		return true
+	}
+	last := info.ModTime()
+	if c.cfg.Clock != nil && info.Size() > 0 {
+		if data, err := c.backend().ReadFile(stamp); err == nil {
+			if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data))); err == nil {
+				last = t
+			}
+		}
+	}
+	return now.Sub(last) >= pruneInterval
+}
+
+// stampContent is what PruneChannel writes into the prune stamp. With the
+// system clock the stamp stays empty and its modification time is the record,
+// as in the bash pruner; a custom clock's time is written out instead, since
+// the filesystem cannot be told what time it is.
+func (c *Client) stampContent(now time.Time) []byte {
+	if c.cfg.Clock == nil {
 		return nil
 	}
+	return []byte(now.UTC().Format(time.RFC3339Nano))
+}
 
-	files := make([]fileInfo, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
+func pruneStampPath(dir string) string {
+	return filepath.Join(dir, ".interband-prune.stamp")
+}
+
+// PruneChannelDryRun returns the paths PruneChannel would remove right now,
+// expired files first, without removing anything or touching the prune
+// stamp. The prune interval is ignored, but MaxPruneRemovals applies as it
+// does to a real pass, assuming every removal succeeds.
+func PruneChannelDryRun(namespace, channel string) ([]string, error) {
+	return envClient().PruneChannelDryRun(namespace, channel)
+}
+
+func (c *Client) PruneChannelDryRun(namespace, channel string) ([]string, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+	entries, protected, err := c.pruneEntries(dir, now)
+	if err != nil {
+		return nil, err
+	}
+	budget := pruneBudget{limit: c.cfg.MaxPruneRemovals}
+	expired, files := splitExpired(entries, c.retention(namespace, channel), now)
+	var out []string
+	for _, entry := range expired {
+		if !budget.spend() {
+			return out, nil
 		}
-		full := filepath.Join(dir, entry.Name())
-		info, err := entry.Info()
-		if err != nil {
-			continue
+		out = append(out, entry.path)
+	}
+	for _, entry := range overflowEntries(files, c.MaxFiles(namespace, channel), protected) {
+		if !budget.spend() {
+			break
+		}
+		out = append(out, entry.path)
+	}
+	return out, nil
+}
+
+// pruneEntries lists the channel files pruning may remove, with the times it
+// ages them by, and counts the files the grace window protects. Protection
+// is judged by modification time before envelope times are applied.
+func (c *Client) pruneEntries(dir string, now time.Time) ([]channelEntry, int, error) {
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	protected := 0
+	if c.cfg.PruneGrace > 0 {
+		cutoff := now.Add(-c.cfg.PruneGrace)
+		candidates := entries[:0]
+		for _, entry := range entries {
+			if entry.modTime.After(cutoff) {
+				protected++
+				continue
+			}
+			candidates = append(candidates, entry)
 		}
-		age := now.Sub(info.ModTime())
-		if age > retention {
-			_ = os.Remove(full)
+		entries = candidates
+	}
+	if c.cfg.PruneByTimestamp {
+		c.useEnvelopeTimes(entries)
+	}
+	if c.cfg.HonorExpiry {
+		c.markExpired(entries, now)
+	}
+	return entries, protected, nil
+}
+
+func (c *Client) retention(namespace, channel string) time.Duration {
+	retention := time.Duration(c.RetentionSeconds(namespace, channel)) * time.Second
+	if retention < 0 {
+		retention = 0
+	}
+	return retention
+}
+
+// splitExpired separates entries older than retention from the rest.
+func splitExpired(entries []channelEntry, retention time.Duration, now time.Time) (expired, kept []channelEntry) {
+	kept = make([]channelEntry, 0, len(entries))
+	for _, entry := range entries {
+		if now.Sub(entry.modTime) > retention {
+			expired = append(expired, entry)
 			continue
 		}
-		files = append(files, fileInfo{path: full, modTime: info.ModTime()})
+		kept = append(kept, entry)
 	}
+	return expired, kept
+}
 
-	maxFiles := MaxFiles(namespace, channel)
-	if maxFiles <= 0 || len(files) <= maxFiles {
+// overflowEntries returns the files beyond the newest maxFiles, sorting files
+// in place. Protected files are not in files but count toward the cap. A
+// maxFiles of zero or less disables the cap.
+func overflowEntries(files []channelEntry, maxFiles, protected int) []channelEntry {
+	if maxFiles <= 0 {
 		return nil
 	}
+	keep := max(maxFiles-protected, 0)
+	if len(files) <= keep {
+		return nil
+	}
+	sortNewestFirst(files)
+	return files[keep:]
+}
 
-	sort.Slice(files, func(i, j int) bool {
+// useEnvelopeTimes replaces each entry's modification time with its envelope
+// timestamp, so age survives copies that rewrite file metadata. Entries whose
+// envelope cannot be read or parsed keep their modification time.
+func (c *Client) useEnvelopeTimes(entries []channelEntry) {
+	for idx := range entries {
+		hdr, err := c.readHeader(entries[idx].path)
+		if err != nil {
+			continue
+		}
+		if t, err := parseEnvelopeTime(hdr.Timestamp); err == nil {
+			entries[idx].modTime = t
+		}
+	}
+}
+
+// sortNewestFirst orders files for the max-files cap: newest modification
+// time first (at the full precision the filesystem records), with equal times
+// ordered by descending file name. Among files written in the same instant the
+// lexically greatest names are therefore the ones kept, so sequential or
+// time-ordered keys keep their latest entries. This deliberately differs from
+// SortEnvelopes: the cap works from directory metadata, or headers with
+// PruneByTimestamp, and ranks files rather than envelopes, so it neither
+// decodes every file for its original key nor splits bundles.
+func sortNewestFirst(files []channelEntry) {
+	sort.SliceStable(files, func(i, j int) bool {
 		if files[i].modTime.Equal(files[j].modTime) {
-			return files[i].path > files[j].path
+			return files[i].name > files[j].name
 		}
 		return files[i].modTime.After(files[j].modTime)
 	})
-
-	for idx := maxFiles; idx < len(files); idx++ {
-		_ = os.Remove(files[idx].path)
-	}
-	return nil
 }
 
 func isNonEmptyString(v any) bool {
@@ -366,40 +1235,13 @@ func isNonEmptyString(v any) bool {
 }
 
 func isNumber(v any) bool {
-	switch v.(type) {
-	case int, int8, int16, int32, int64,
-		uint, uint8, uint16, uint32, uint64,
-		float32, float64, json.Number:
-		return true
-	default:
-		return false
-	}
+	_, ok := toFloat64(v)
+	return ok
 }
 
 func isNonNegativeNumber(v any) bool {
-	switch n := v.(type) {
-	case int:
-		return n >= 0
-	case int8:
-		return n >= 0
-	case int16:
-		return n >= 0
-	case int32:
-		return n >= 0
-	case int64:
-		return n >= 0
-	case uint, uint8, uint16, uint32, uint64:
-		return true
-	case float32:
-		return n >= 0
-	case float64:
-		return n >= 0
-	case json.Number:
-		f, err := n.Float64()
-		return err == nil && f >= 0
-	default:
-		return false
-	}
+	n, ok := toFloat64(v)
+	return ok && n >= 0
 }
 
 func parseEnvInt(name string) (int, bool) {
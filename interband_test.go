@@ -1,11 +1,20 @@
 package interband
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestSafeKey(t *testing.T) {
@@ -15,6 +24,171 @@ func TestSafeKey(t *testing.T) {
 	}
 }
 
+func TestSafeKeyUnicode(t *testing.T) {
+	inputs := []string{"résumé", "resume", "日本語", "中文", "a/b\tc\x00"}
+	seen := map[string]string{}
+	for _, in := range inputs {
+		got := SafeKeyUnicode(in)
+		if prev, dup := seen[got]; dup {
+			t.Fatalf("%q and %q both map to %q", prev, in, got)
+		}
+		seen[got] = in
+	}
+	if got := SafeKeyUnicode("a/b\tc\x00"); got != "a_b_c_" {
+		t.Fatalf("unexpected sanitized key: %q", got)
+	}
+	if got := SafeKey("résumé"); got != "r_sum_" {
+		t.Fatalf("default SafeKey should stay ASCII-only, got %q", got)
+	}
+
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_UNICODE_KEYS", "1")
+	p, err := Path("custom", "labels", "日本語")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if filepath.Base(p) != "日本語.json" {
+		t.Fatalf("unexpected path: %s", p)
+	}
+}
+
+func TestLongKeysAreCutWithHash(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	// Cutting is opt-in, so by default Go names long keys as bash does and
+	// rejects those no file system could store.
+	long := strings.Repeat("k", 300)
+	fits := strings.Repeat("k", 247)
+	if p, _ := Path("custom", "events", fits); filepath.Base(p) != fits+".json" {
+		t.Fatalf("long keys should not be cut by default: %s", p)
+	}
+	if _, err := Path("custom", "events", long); !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "MaxKeyBytes") {
+		t.Fatalf("expected a validation error suggesting MaxKeyBytes, got %v", err)
+	}
+
+	t.Setenv("INTERBAND_MAX_KEY_BYTES", strconv.Itoa(DefaultMaxKeyBytes))
+	p, err := Path("custom", "events", long)
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	name := filepath.Base(p)
+	if len(name) != DefaultMaxKeyBytes+len(".json") || !strings.HasPrefix(name, "kkk") {
+		t.Fatalf("unexpected name %q (%d bytes)", name, len(name))
+	}
+	if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write with long key failed: %v", err)
+	}
+	if ok, _ := Exists("custom", "events", long); !ok {
+		t.Fatal("long key should round-trip")
+	}
+	other, _ := Path("custom", "events", long+"x")
+	if other == p {
+		t.Fatal("keys sharing a long prefix collided")
+	}
+
+	// Cuts land on rune boundaries.
+	t.Setenv("INTERBAND_UNICODE_KEYS", "1")
+	t.Setenv("INTERBAND_MAX_KEY_BYTES", "40")
+	p, _ = Path("custom", "events", strings.Repeat("é", 100))
+	if stem := strings.TrimSuffix(filepath.Base(p), ".json"); len(stem) > 40 || !utf8.ValidString(stem) {
+		t.Fatalf("bad cut: %q", stem)
+	}
+
+	t.Setenv("INTERBAND_MAX_KEY_BYTES", "0")
+	p, _ = Path("custom", "events", fits)
+	if filepath.Base(p) != fits+".json" {
+		t.Fatal("a zero limit should disable cutting")
+	}
+}
+
+func TestSafeKeyPortable(t *testing.T) {
+	cases := map[string]string{
+		"CON":      "_CON",
+		"nul":      "_nul",
+		"com1":     "_com1",
+		"aux.json": "_aux.json",
+		"Lpt9.x.y": "_Lpt9.x.y",
+		"name.":    "name_",
+		"name..":   "name__",
+		"console":  "console",
+		"com10":    "com10",
+		"a/b":      "a_b",
+	}
+	for raw, want := range cases {
+		if got := SafeKeyPortable(raw); got != want {
+			t.Fatalf("SafeKeyPortable(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PORTABLE_KEYS", "1")
+	p, _ := Path("custom", "events", "CON")
+	if filepath.Base(p) != "_CON.json" {
+		t.Fatalf("Path did not apply portable keys: %s", p)
+	}
+}
+
+func TestSafeKeyUniqueAvoidsCollisions(t *testing.T) {
+	if got := SafeKeyUnique("plain-key.1"); got != "plain-key.1" {
+		t.Fatalf("clean key should be unchanged, got %q", got)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.UniqueKeys = true
+	c := NewClient(cfg)
+
+	raws := []string{"a/b", "a b", "a?b"}
+	for _, raw := range raws {
+		p, err := c.Path("custom", "events", raw)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{"raw": raw}); err != nil {
+			t.Fatalf("write %q: %v", raw, err)
+		}
+	}
+	for _, raw := range raws {
+		p, _ := c.Path("custom", "events", raw)
+		payload, err := c.ReadPayload(p)
+		if err != nil {
+			t.Fatalf("read %q: %v", raw, err)
+		}
+		if payload["raw"] != raw {
+			t.Fatalf("key %q was overwritten by %v", raw, payload["raw"])
+		}
+	}
+}
+
+func TestPathRejectsEscapingSegments(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("INTERBAND_ROOT", root)
+
+	bad := []string{"..", ".", "../../etc", "/etc", "a/b", `a\b`, ".hidden", "a\x00b"}
+	for _, seg := range bad {
+		if _, err := Path(seg, "ch", "k"); !errors.Is(err, ErrValidation) {
+			t.Fatalf("namespace %q: expected validation error, got %v", seg, err)
+		}
+		if _, err := ChannelDir("ns", seg); !errors.Is(err, ErrValidation) {
+			t.Fatalf("channel %q: expected validation error, got %v", seg, err)
+		}
+		if err := PruneChannel(seg, "ch"); err == nil {
+			t.Fatalf("prune of %q should fail", seg)
+		}
+		if _, err := Channels(seg); err == nil {
+			t.Fatalf("Channels(%q) should fail", seg)
+		}
+	}
+
+	p, err := Path("ns..x", "ch", "../../k")
+	if err != nil {
+		t.Fatalf("dots inside a segment are fine: %v", err)
+	}
+	if rel, err := filepath.Rel(root, p); err != nil || strings.HasPrefix(rel, "..") {
+		t.Fatalf("path escaped root: %s", p)
+	}
+}
+
 func TestPathAndWriteReadKnownPayload(t *testing.T) {
 	t.Setenv("INTERBAND_ROOT", t.TempDir())
 
@@ -107,6 +281,116 @@ func TestReadRejectsInvalidEnvelopeVersion(t *testing.T) {
 	}
 }
 
+func TestReadPayloadInto(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, _ := Path("custom", "events", "big")
+	if err := Write(p, "custom", "anything", "s", map[string]any{"id": 12345678901, "name": "n", "extra": 2}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	var got struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := ReadPayloadInto(p, &got); err != nil {
+		t.Fatalf("read into struct failed: %v", err)
+	}
+	if got.ID != 12345678901 || got.Name != "n" {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+	var loose map[string]any
+	if err := ReadPayloadInto(p, &loose); err != nil {
+		t.Fatalf("read into map failed: %v", err)
+	}
+	if _, ok := loose["extra"].(json.Number); !ok {
+		t.Fatalf("expected json.Number, got %T", loose["extra"])
+	}
+	var wrong struct {
+		Name int `json:"name"`
+	}
+	if err := ReadPayloadInto(p, &wrong); err == nil {
+		t.Fatal("expected type mismatch to fail")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	raw := `{"version":"1.0.0","namespace":"interphase","type":"bead_phase","timestamp":"2024-01-01T00:00:00Z","payload":{"id":"x"}}`
+	if err := os.WriteFile(bad, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	into := map[string]any{}
+	if err := ReadPayloadInto(bad, &into); !errors.Is(err, ErrValidation) || len(into) != 0 {
+		t.Fatalf("expected validation failure before decoding, got %v %v", err, into)
+	}
+}
+
+func TestDecodeAndUnmarshalEnvelope(t *testing.T) {
+	t.Setenv("INTERBAND_MAX_PAYLOAD_BYTES", "512")
+	env, err := NewEnvelope("custom", "anything").Set("k", "v").Build()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	data, _ := json.Marshal(env)
+
+	got, err := UnmarshalEnvelope(data)
+	if err != nil || got.Payload["k"] != "v" {
+		t.Fatalf("unmarshal failed: %+v %v", got, err)
+	}
+	if got, err = DecodeEnvelope(bytes.NewReader(data)); err != nil || got.Timestamp != env.Timestamp {
+		t.Fatalf("decode failed: %+v %v", got, err)
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	if _, err := DecodeEnvelope(&gz); err != nil {
+		t.Fatalf("decode of gzip input failed: %v", err)
+	}
+
+	if _, err := UnmarshalEnvelope([]byte(`{"version":"1.0.0"}`)); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if _, err := UnmarshalEnvelope([]byte(`{`)); err == nil {
+		t.Fatal("expected malformed JSON to fail")
+	}
+	big := strings.NewReader(`{"payload":"` + strings.Repeat("x", 1024) + `"}`)
+	if _, err := DecodeEnvelope(big); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected size limit, got %v", err)
+	}
+}
+
+func TestReadEnvelopeStrictRejectsUnknownWrapperKeys(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content map[string]any) string {
+		raw, _ := json.Marshal(content)
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, raw, 0o644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+		return p
+	}
+	ts := time.Now().UTC().Format(time.RFC3339)
+	good := write("good.json", map[string]any{
+		"version": "1.0.0", "namespace": "custom", "type": "anything", "session_id": "s",
+		"timestamp": ts, "payload": map[string]any{"anything": "goes", "extra": 1},
+	})
+	typo := write("typo.json", map[string]any{
+		"version": "1.0.0", "namespace": "custom", "type": "anything", "session_id": "s",
+		"timestamp": ts, "timestmap": ts, "payload": map[string]any{},
+	})
+
+	if _, err := ReadEnvelopeStrict(good); err != nil {
+		t.Fatalf("strict read of a clean envelope failed: %v", err)
+	}
+	if _, err := ReadEnvelope(typo); err != nil {
+		t.Fatalf("lenient read should ignore the extra key: %v", err)
+	}
+	_, err := ReadEnvelopeStrict(typo)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "timestmap" {
+		t.Fatalf("expected validation error naming the typo, got %v", err)
+	}
+}
+
 func TestPruneChannelRetention(t *testing.T) {
 	root := t.TempDir()
 	t.Setenv("INTERBAND_ROOT", root)
@@ -189,3 +473,386 @@ func TestPruneChannelMaxFiles(t *testing.T) {
 		t.Fatalf("expected c.json to remain: %v", err)
 	}
 }
+
+func TestValidatePayloadResultReportsSchemaUse(t *testing.T) {
+	validated, err := ValidatePayloadResult("interphase", "bead_phase", map[string]any{
+		"id":    "iv-hoqj",
+		"phase": "planned",
+		"ts":    1,
+	})
+	if err != nil || !validated {
+		t.Fatalf("expected known type to validate, validated=%v err=%v", validated, err)
+	}
+
+	validated, err = ValidatePayloadResult("custom", "anything", map[string]any{"k": "v"})
+	if err != nil || validated {
+		t.Fatalf("expected unknown type to pass unvalidated, validated=%v err=%v", validated, err)
+	}
+
+	validated, err = ValidatePayloadResult("clavain", "dispatch", map[string]any{"name": "x"})
+	if err == nil || !validated {
+		t.Fatalf("expected known type to fail validation, validated=%v err=%v", validated, err)
+	}
+}
+
+func TestReadToleratesUnknownPhaseUnlessStrict(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, err := Path("interphase", "bead", "future")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+
+	content := map[string]any{
+		"version":    "1.0.0",
+		"namespace":  "interphase",
+		"type":       "bead_phase",
+		"session_id": "s",
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+		"payload":    map[string]any{"id": "iv-1", "phase": "polishing", "ts": 1},
+	}
+	raw, _ := json.Marshal(content)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(p, raw, 0o644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	if _, err := ReadEnvelope(p); err != nil {
+		t.Fatalf("expected lenient read to accept unknown phase: %v", err)
+	}
+
+	t.Setenv("INTERBAND_STRICT_READS", "1")
+	if _, err := ReadEnvelope(p); err == nil {
+		t.Fatal("expected strict read to reject unknown phase")
+	}
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_TIMESTAMP_PRECISION", "ns")
+
+	p, err := Path("custom", "events", "nano")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	env, err := ReadEnvelope(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, env.Timestamp); err != nil {
+		t.Fatalf("timestamp %q not RFC3339Nano: %v", env.Timestamp, err)
+	}
+
+	t.Setenv("INTERBAND_TIMESTAMP_PRECISION", "s")
+	if TimestampLayout() != time.RFC3339 {
+		t.Fatalf("expected second precision, got %q", TimestampLayout())
+	}
+	t.Setenv("INTERBAND_TIMESTAMP_PRECISION", "")
+	if TimestampLayout() != time.RFC3339Nano {
+		t.Fatalf("expected nanosecond precision by default, got %q", TimestampLayout())
+	}
+}
+
+func TestRapidWritesGetDistinctTimestamps(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	seen := map[string]bool{}
+	for idx := 0; idx < 5; idx++ {
+		p, err := Path("interlock", "coordination", strconv.Itoa(idx))
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		env, err := ReadEnvelope(p)
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if seen[env.Timestamp] {
+			t.Fatalf("duplicate timestamp %q", env.Timestamp)
+		}
+		seen[env.Timestamp] = true
+	}
+}
+
+func TestEnvelopeTimeFormats(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	cases := map[string]time.Time{
+		"2024-01-02T03:04:05Z":        want.Truncate(time.Second),
+		"2024-01-02T03:04:05.123456Z": want,
+		"1704164645":                  want.Truncate(time.Second),
+		"1704164645123":               want.Truncate(time.Millisecond),
+	}
+	for raw, expected := range cases {
+		got, err := Envelope{Timestamp: raw}.Time()
+		if err != nil {
+			t.Fatalf("%q: parse failed: %v", raw, err)
+		}
+		if !got.Equal(expected) {
+			t.Fatalf("%q: expected %v, got %v", raw, expected, got)
+		}
+	}
+
+	env := Envelope{Version: "1.0.0", Namespace: "custom", Type: "x", Timestamp: "yesterday", Payload: map[string]any{}}
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatal("expected unparseable timestamp to be rejected")
+	}
+
+	var decoded Envelope
+	raw := `{"version":"1.0.0","namespace":"custom","type":"x","timestamp":1704164645123,"payload":{}}`
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		t.Fatalf("decode numeric timestamp failed: %v", err)
+	}
+	if err := ValidateEnvelope(decoded); err != nil {
+		t.Fatalf("expected numeric epoch timestamp to validate: %v", err)
+	}
+}
+
+func TestWriteEnvelopeKeepsCallerFields(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, err := Path("custom", "events", "fixture")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+
+	env := Envelope{
+		Version:   "1.2.0",
+		Namespace: "custom",
+		Type:      "anything",
+		SessionID: "s",
+		Timestamp: "2024-01-02T03:04:05Z",
+		Payload:   map[string]any{"k": "v"},
+	}
+	if err := WriteEnvelope(p, env); err != nil {
+		t.Fatalf("write envelope failed: %v", err)
+	}
+	got, err := ReadEnvelope(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if got.Version != env.Version || got.Timestamp != env.Timestamp {
+		t.Fatalf("expected caller fields preserved, got %+v", got)
+	}
+
+	env.Version = "2.0.0"
+	if err := WriteEnvelope(p, env); err == nil {
+		t.Fatal("expected unsupported version to be rejected")
+	}
+}
+
+func TestContextVariantsFailFast(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, err := Path("custom", "events", "ctx")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := WriteContext(ctx, p, "custom", "anything", "s", map[string]any{"k": "v"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled write, got %v", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("expected no file after cancelled write, stat err=%v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(p))
+	if len(entries) != 0 {
+		t.Fatalf("expected no temp residue, found %d entries", len(entries))
+	}
+
+	if err := WriteContext(context.Background(), p, "custom", "anything", "s", map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := ReadEnvelopeContext(ctx, p); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled read, got %v", err)
+	}
+	if _, err := ReadEnvelopeContext(context.Background(), p); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+}
+
+func TestMaxPayloadBytes(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_MAX_PAYLOAD_BYTES", "256")
+	p, err := Path("custom", "events", "big")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	big := map[string]any{"blob": strings.Repeat("x", 512)}
+	if err := Write(p, "custom", "anything", "s", big); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge on write, got %v", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("oversized write should leave no file, stat err=%v", err)
+	}
+
+	t.Setenv("INTERBAND_MAX_PAYLOAD_BYTES", "0")
+	if err := Write(p, "custom", "anything", "s", big); err != nil {
+		t.Fatalf("write with limit disabled failed: %v", err)
+	}
+	t.Setenv("INTERBAND_MAX_PAYLOAD_BYTES", "256")
+	if _, err := ReadEnvelope(p); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge on read, got %v", err)
+	}
+	t.Setenv("INTERBAND_MAX_PAYLOAD_BYTES", "")
+	if _, err := ReadEnvelope(p); err != nil {
+		t.Fatalf("default limit should allow read: %v", err)
+	}
+
+	// A zero Config gets the default limit; only a negative one disables it.
+	huge := map[string]any{"blob": strings.Repeat("x", DefaultMaxPayloadBytes)}
+	c := NewClient(Config{Root: t.TempDir()})
+	if got := c.Config().MaxPayloadBytes; got != DefaultMaxPayloadBytes {
+		t.Fatalf("zero MaxPayloadBytes should default to %d, got %d", DefaultMaxPayloadBytes, got)
+	}
+	hp, _ := c.Path("custom", "events", "huge")
+	if err := c.Write(hp, "custom", "anything", "s", huge); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge from a zero Config, got %v", err)
+	}
+	c = NewClient(Config{Root: t.TempDir(), MaxPayloadBytes: -1})
+	hp, _ = c.Path("custom", "events", "huge")
+	if err := c.Write(hp, "custom", "anything", "s", huge); err != nil {
+		t.Fatalf("negative MaxPayloadBytes should disable the limit: %v", err)
+	}
+}
+
+func TestFileAndDirModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions")
+	}
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.FileMode = 0o640
+	cfg.DirMode = os.ModeSetgid | 0o775
+	c := NewClient(cfg)
+
+	p, err := c.Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if got := info.Mode().Perm(); got != 0o640 {
+		t.Fatalf("file mode = %o, want 640", got)
+	}
+	for _, dir := range []string{filepath.Dir(p), filepath.Dir(filepath.Dir(p))} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("stat failed: %v", err)
+		}
+		if info.Mode().Perm() != 0o775 || info.Mode()&os.ModeSetgid == 0 {
+			t.Fatalf("dir %s mode = %v, want setgid 0775", dir, info.Mode())
+		}
+	}
+
+	t.Setenv("INTERBAND_DIR_MODE", "2770")
+	t.Setenv("INTERBAND_FILE_MODE", "0600")
+	if got := envClient().Config(); got.DirMode != os.ModeSetgid|0o770 || got.FileMode != 0o600 {
+		t.Fatalf("unexpected env modes: %v %v", got.DirMode, got.FileMode)
+	}
+}
+
+func TestKnownChannelsMatchBashDefaults(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("lib", "interband.sh"))
+	if err != nil {
+		t.Fatalf("read bash lib: %v", err)
+	}
+	bash := map[string]map[ChannelRef]int{}
+	fn := ""
+	line := regexp.MustCompile(`^\s*([a-z0-9_-]+):([a-z0-9_-]+)\)\s+echo "(\d+)"`)
+	for _, l := range strings.Split(string(raw), "\n") {
+		if name, ok := strings.CutSuffix(strings.TrimSpace(l), "() {"); ok {
+			fn = name
+			continue
+		}
+		if m := line.FindStringSubmatch(l); m != nil {
+			if bash[fn] == nil {
+				bash[fn] = map[ChannelRef]int{}
+			}
+			n, _ := strconv.Atoi(m[3])
+			bash[fn][ChannelRef{Namespace: m[1], Channel: m[2]}] = n
+		}
+	}
+
+	known := KnownChannels()
+	if len(known) != len(bash["interband_default_retention_secs"]) || len(known) != len(bash["interband_default_max_files"]) {
+		t.Fatalf("go has %d channels, bash has %v", len(known), bash)
+	}
+	for _, d := range known {
+		ref := ChannelRef{Namespace: d.Namespace, Channel: d.Channel}
+		if bash["interband_default_retention_secs"][ref] != d.RetentionSeconds || bash["interband_default_max_files"][ref] != d.MaxFiles {
+			t.Fatalf("%v drifted from bash: %+v", ref, d)
+		}
+		if DefaultRetentionSeconds(d.Namespace, d.Channel) != d.RetentionSeconds || DefaultMaxFiles(d.Namespace, d.Channel) != d.MaxFiles {
+			t.Fatalf("lookup disagrees with table for %v", ref)
+		}
+	}
+	if DefaultRetentionSeconds("custom", "events") != 86400 || DefaultMaxFiles("custom", "events") != 256 {
+		t.Fatal("unexpected fallback defaults")
+	}
+
+	known[0].MaxFiles = -1
+	if KnownChannels()[0].MaxFiles == -1 {
+		t.Fatal("KnownChannels must return a copy")
+	}
+}
+
+func TestValidateAndValidateEnvelopeBytes(t *testing.T) {
+	good := map[string]any{"id": "iv-1", "phase": "planned", "reason": "r", "ts": 1}
+	if err := Validate("interphase", "bead_phase", good); err != nil {
+		t.Fatalf("valid payload rejected: %v", err)
+	}
+	if err := Validate("interphase", "bead_phase", map[string]any{"id": "iv-1"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if err := Validate("", "bead_phase", good); err == nil {
+		t.Fatal("expected error for empty namespace")
+	}
+
+	raw := []byte(`{"version":"1.0.0","namespace":"interphase","type":"bead_phase","session_id":"s","timestamp":"2026-01-02T03:04:05Z","payload":{"id":"iv-1","phase":"planned","reason":"r","ts":1}}`)
+	if err := ValidateEnvelopeBytes(raw); err != nil {
+		t.Fatalf("valid envelope rejected: %v", err)
+	}
+	if err := ValidateEnvelopeBytes([]byte(`{"version":"1.0.0"`)); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error for malformed JSON, got %v", err)
+	}
+	if err := ValidateEnvelopeBytes([]byte(`{"version":"2.0.0","namespace":"a","type":"b","timestamp":"2026-01-02T03:04:05Z","payload":{}}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected unsupported version, got %v", err)
+	}
+}
+
+func TestValidateEnvelopeRejectsUnsafeNames(t *testing.T) {
+	env := Envelope{Version: "1.0.0", Namespace: "custom", Type: "status.v2_x-y", Timestamp: "2026-01-02T03:04:05Z", Payload: map[string]any{}}
+	if err := ValidateEnvelope(env); err != nil {
+		t.Fatalf("safe names rejected: %v", err)
+	}
+	cases := []struct{ field, namespace, typ string }{
+		{"namespace", "inter/phase", "t"},
+		{"namespace", "..", "t"},
+		{"namespace", "ns\x01", "t"},
+		{"type", "custom", "bead phase"},
+		{"type", "custom", "a/b"},
+		{"type", "custom", "faseé"},
+	}
+	for _, tc := range cases {
+		bad := env
+		bad.Namespace, bad.Type = tc.namespace, tc.typ
+		var verr *ValidationError
+		if err := ValidateEnvelope(bad); !errors.As(err, &verr) || verr.Field != tc.field {
+			t.Fatalf("%q/%q: expected a %s validation error, got %v", tc.namespace, tc.typ, tc.field, err)
+		}
+		if err := Validate(tc.namespace, tc.typ, map[string]any{}); !errors.Is(err, ErrValidation) {
+			t.Fatalf("Validate(%q, %q) should fail, got %v", tc.namespace, tc.typ, err)
+		}
+	}
+}
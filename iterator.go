@@ -0,0 +1,147 @@
+package interband
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ChannelIterator streams the envelopes of a channel one file at a time, in
+// the same order as ListChannel (see SortEnvelopes), expanding bundle files
+// as ListChannel does. Only the envelope headers are held in memory between
+// calls to Next, plus the most recently read bundle. Expired envelopes are
+// skipped without being reported when the client honors expiry.
+type ChannelIterator struct {
+	// StopOnError makes Next return false at the first file that fails to
+	// decode or validate. By default such files are skipped and reported by
+	// Err.
+	StopOnError bool
+
+	c       *Client
+	entries []iterEntry
+	idx     int
+	cur     Envelope
+	errs    []error
+	stopped bool
+
+	// The last file read, kept so the items of a bundle are decoded once.
+	loaded    string
+	loadedEnv []Envelope
+	failed    map[string]struct{}
+}
+
+type iterEntry struct {
+	key  string
+	orig string // the envelope's recorded Key
+	path string
+	at   time.Time
+	seq  int // position within a bundle file
+}
+
+// NewChannelIterator lists a channel and returns an iterator over it. Files
+// added after the call are not visited. A missing channel yields an empty
+// iterator.
+func NewChannelIterator(namespace, channel string) (*ChannelIterator, error) {
+	return envClient().NewChannelIterator(namespace, channel)
+}
+
+func (c *Client) NewChannelIterator(namespace, channel string) (*ChannelIterator, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]iterEntry, 0, len(entries))
+	for _, entry := range entries {
+		hdrs, err := c.readHeaders(entry.path)
+		if err != nil || len(hdrs) == 0 {
+			// Unreadable headers sort first and surface their error from Next.
+			items = append(items, iterEntry{key: entry.key, path: entry.path})
+			continue
+		}
+		for idx, hdr := range hdrs {
+			items = append(items, iterEntry{key: entry.key, orig: hdr.Key, path: entry.path, at: parseTimestamp(hdr.Timestamp), seq: idx})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if c := compareOrder(items[i].at, items[i].orig, items[j].at, items[j].orig); c != 0 {
+			return c < 0
+		}
+		if items[i].key != items[j].key {
+			return items[i].key < items[j].key
+		}
+		return items[i].seq < items[j].seq
+	})
+	return &ChannelIterator{c: c, entries: items}, nil
+}
+
+// Next advances to the next readable envelope and reports whether there is
+// one.
+func (it *ChannelIterator) Next() bool {
+	if it.stopped {
+		return false
+	}
+	for it.idx < len(it.entries) {
+		entry := it.entries[it.idx]
+		it.idx++
+		env, ok, err := it.read(entry)
+		if err != nil {
+			it.c.skip(entry.path, err)
+			it.errs = append(it.errs, FileError{Path: entry.path, Err: err})
+			if it.StopOnError {
+				it.stopped = true
+				return false
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		it.cur = env
+		return true
+	}
+	it.stopped = true
+	return false
+}
+
+// read returns the envelope entry refers to and whether it is live, as
+// opposed to expired or gone since listing. A file that cannot be read fails
+// once, for its first item.
+func (it *ChannelIterator) read(entry iterEntry) (Envelope, bool, error) {
+	if _, ok := it.failed[entry.path]; ok {
+		return Envelope{}, false, nil
+	}
+	if entry.path != it.loaded {
+		envs, err := it.c.readChannelFile(entry.path)
+		if err != nil {
+			if it.failed == nil {
+				it.failed = make(map[string]struct{})
+			}
+			it.failed[entry.path] = struct{}{}
+			return Envelope{}, false, err
+		}
+		it.loaded, it.loadedEnv = entry.path, envs
+	}
+	if entry.seq >= len(it.loadedEnv) {
+		return Envelope{}, false, nil
+	}
+	env := it.loadedEnv[entry.seq]
+	if it.c.expired(env) {
+		return Envelope{}, false, nil
+	}
+	return env, true, nil
+}
+
+// Envelope returns the envelope Next advanced to.
+func (it *ChannelIterator) Envelope() Envelope {
+	return it.cur
+}
+
+// Err returns the FileErrors for files skipped so far, joined, or nil.
+func (it *ChannelIterator) Err() error {
+	return errors.Join(it.errs...)
+}
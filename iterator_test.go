@@ -0,0 +1,50 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChannelIterator(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeAt(t, "custom", "events", "late", base.Add(2*time.Second), map[string]any{"n": 3})
+	writeAt(t, "custom", "events", "b-tie", base, map[string]any{"n": 2})
+	writeAt(t, "custom", "events", "a-tie", base, map[string]any{"n": 1})
+	dir, _ := ChannelDir("custom", "events")
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write bad failed: %v", err)
+	}
+
+	it, err := NewChannelIterator("custom", "events")
+	if err != nil {
+		t.Fatalf("iterator failed: %v", err)
+	}
+	var got []any
+	for it.Next() {
+		got = append(got, it.Envelope().Payload["n"])
+	}
+	if len(got) != 3 || got[0] != float64(1) || got[1] != float64(2) || got[2] != float64(3) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+	var fe FileError
+	if !errors.As(it.Err(), &fe) || filepath.Base(fe.Path) != "bad.json" {
+		t.Fatalf("expected FileError for bad.json, got %v", it.Err())
+	}
+
+	it, err = NewChannelIterator("custom", "events")
+	if err != nil {
+		t.Fatalf("iterator failed: %v", err)
+	}
+	it.StopOnError = true
+	if it.Next() {
+		t.Fatalf("expected to stop at the undecodable file first, got %+v", it.Envelope())
+	}
+	if it.Err() == nil {
+		t.Fatal("expected error after stopping")
+	}
+}
@@ -0,0 +1,69 @@
+package interband
+
+import "context"
+
+// WriteKey writes a message for key into a channel like Write to the key's
+// Path, and records key in the envelope so ReadChannelMap can return it even
+// when SafeKey changed it.
+func WriteKey(namespace, channel, key, typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	return envClient().WriteKey(namespace, channel, key, typ, sessionID, payload, opts...)
+}
+
+func (c *Client) WriteKey(namespace, channel, key, typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return err
+	}
+	env, err := c.newEnvelopeWith(p, namespace, typ, sessionID, payload, opts)
+	if err != nil {
+		return err
+	}
+	env.Key = key
+	return c.writeEnvelope(context.Background(), p, env)
+}
+
+// ReadChannelMap reads a channel into a map keyed by each envelope's original
+// key, falling back to the file name's key for envelopes that do not record
+// one. When several envelopes share a key, as bundle items do, the one
+// ListChannel would order last wins. Unreadable files are skipped; the error
+// is only set when the channel itself cannot be read.
+func ReadChannelMap(namespace, channel string) (map[string]Envelope, error) {
+	return envClient().ReadChannelMap(namespace, channel)
+}
+
+func (c *Client) ReadChannelMap(namespace, channel string) (map[string]Envelope, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []keyedEnvelope
+	for _, entry := range entries {
+		envs, err := c.ReadBundle(entry.path)
+		if err != nil {
+			c.skip(entry.path, err)
+			continue
+		}
+		for idx, env := range envs {
+			if c.expired(env) {
+				continue
+			}
+			items = append(items, keyedEnvelope{key: entry.key, seq: idx, at: parseTimestamp(env.Timestamp), env: env})
+		}
+	}
+	sortKeyed(items)
+
+	out := make(map[string]Envelope, len(items))
+	for _, item := range items {
+		key := item.env.Key
+		if key == "" {
+			key = item.key
+		}
+		out[key] = item.env
+	}
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteKeyReadChannelMap(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	c := NewClient(cfg)
+
+	raw := "Bead: iv-1/α"
+	if err := c.WriteKey("custom", "state", raw, "anything", "s", map[string]any{"v": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	p, _ := c.Path("custom", "state", raw)
+	env, err := c.ReadEnvelope(p)
+	if err != nil || env.Key != raw {
+		t.Fatalf("envelope key = %q, %v; want %q", env.Key, err, raw)
+	}
+	if _, err := c.ReadEnvelopeStrict(p); err != nil {
+		t.Fatalf("strict read should accept the key field: %v", err)
+	}
+
+	// A file without the field, as older writers and the bash helpers
+	// produce, falls back to the key in its name.
+	legacy, _ := c.Path("custom", "state", "legacy")
+	if err := c.Write(legacy, "custom", "anything", "s", map[string]any{"v": 2}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(p), "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ReadChannelMap("custom", "state")
+	if err != nil {
+		t.Fatalf("read map failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 keys, got %v", got)
+	}
+	if got[raw].Payload["v"] != 1.0 || got["legacy"].Payload["v"] != 2.0 {
+		t.Fatalf("unexpected map: %v", got)
+	}
+
+	empty, err := c.ReadChannelMap("custom", "missing")
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("missing channel: %v, %v", empty, err)
+	}
+}
+
+func TestKeyedWritersRecordKey(t *testing.T) {
+	c, _ := newMemClient(t)
+	if _, err := c.WriteIfAbsent("custom", "leases", "Lease A", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write if absent failed: %v", err)
+	}
+	if err := c.Update("custom", "leases", "Lease B", "anything", "s", func(p map[string]any) (map[string]any, error) {
+		return p, nil
+	}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if _, err := c.WriteBatch("custom", "leases", []BatchItem{{Key: "Lease C", Type: "anything", Payload: map[string]any{}}}); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	got, err := c.ReadChannelMap("custom", "leases")
+	if err != nil {
+		t.Fatalf("read map failed: %v", err)
+	}
+	for _, key := range []string{"Lease A", "Lease B", "Lease C"} {
+		if got[key].Key != key {
+			t.Fatalf("missing %q in %v", key, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package interband
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// Layout maps namespaces, channels, and keys onto directories and files.
+// Every read, write, prune, and watch goes through the client's layout.
+// Namespaces, Channels, PruneAll, and TotalUsage still discover channels as
+// Root/namespace/channel, so layouts should keep channel directories there.
+type Layout interface {
+	// ChannelDir returns the directory holding a channel.
+	ChannelDir(root, namespace, channel string) string
+	// KeyPath returns where the envelope file named fileName (a sanitized key
+	// plus ".json") lives within channelDir.
+	KeyPath(channelDir, fileName string) string
+	// IsBucket reports whether a subdirectory of a channel directory holds
+	// envelope files that belong to the channel.
+	IsBucket(name string) bool
+}
+
+// FlatLayout stores every key directly in Root/namespace/channel. It is the
+// default and the only layout the bash helpers understand.
+type FlatLayout struct{}
+
+func (FlatLayout) ChannelDir(root, namespace, channel string) string {
+	return filepath.Join(root, namespace, channel)
+}
+
+func (FlatLayout) KeyPath(channelDir, fileName string) string {
+	return filepath.Join(channelDir, fileName)
+}
+
+func (FlatLayout) IsBucket(string) bool { return false }
+
+// ShardedLayout spreads a channel's keys over 256 bucket directories named by
+// the first byte of a hash of the file name, e.g. channel/3f/key.json, for
+// channels large enough to strain a single directory. Switching an existing
+// channel between layouts orphans its files under the old paths.
+type ShardedLayout struct{}
+
+func (ShardedLayout) ChannelDir(root, namespace, channel string) string {
+	return filepath.Join(root, namespace, channel)
+}
+
+func (ShardedLayout) KeyPath(channelDir, fileName string) string {
+	sum := sha256.Sum256([]byte(fileName))
+	return filepath.Join(channelDir, hex.EncodeToString(sum[:1]), fileName)
+}
+
+func (ShardedLayout) IsBucket(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	for _, r := range name {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// layout returns the configured layout, defaulting to FlatLayout.
+func (c *Client) layout() Layout {
+	if c.cfg.Layout == nil {
+		return FlatLayout{}
+	}
+	return c.cfg.Layout
+}
+
+// envLayout maps INTERBAND_LAYOUT ("flat" or "sharded") to a Layout. Unset or
+// unknown values yield nil, the flat default.
+func envLayout(raw string) Layout {
+	if raw == "sharded" {
+		return ShardedLayout{}
+	}
+	return nil
+}
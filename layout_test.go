@@ -0,0 +1,53 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShardedLayout(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("INTERBAND_ROOT", root)
+	t.Setenv("INTERBAND_LAYOUT", "sharded")
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+	t.Setenv("INTERBAND_MAX_FILES", "3")
+
+	dir, _ := ChannelDir("custom", "events")
+	keys := []string{"a", "b", "c", "d", "e"}
+	for _, key := range keys {
+		p, err := Path("custom", "events", key)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		bucket := filepath.Dir(p)
+		if filepath.Dir(bucket) != dir || !(ShardedLayout{}).IsBucket(filepath.Base(bucket)) {
+			t.Fatalf("%s is not in a bucket of %s", p, dir)
+		}
+		if err := Write(p, "custom", "anything", "s", map[string]any{"key": key}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	// Directories that are not buckets are ignored.
+	if err := os.MkdirAll(filepath.Join(dir, "notes"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+
+	if ok, _ := Exists("custom", "events", "c"); !ok {
+		t.Fatal("expected key to exist through the layout")
+	}
+	envs, skipped, err := ListChannel("custom", "events")
+	if err != nil || len(envs) != len(keys) || len(skipped) != 0 {
+		t.Fatalf("unexpected listing: %d %v %v", len(envs), skipped, err)
+	}
+	stats, err := PruneChannelStats("custom", "events")
+	if err != nil || stats.Overflow != 2 || stats.Remaining != 3 {
+		t.Fatalf("unexpected prune: %+v %v", stats, err)
+	}
+
+	t.Setenv("INTERBAND_LAYOUT", "")
+	flat, _ := Path("custom", "events", "a")
+	if flat != filepath.Join(dir, "a.json") {
+		t.Fatalf("unexpected flat path: %s", flat)
+	}
+}
@@ -0,0 +1,43 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ReadLegacyPayload reads a file holding a bare payload object, written before
+// the envelope format, and validates it as a payload of the assumed namespace
+// and type. Write the result to wrap it in an envelope. Compressed files are
+// accepted and MaxPayloadBytes applies. A file that is already an envelope is
+// rejected so it is not wrapped twice.
+func ReadLegacyPayload(sourcePath, assumedNamespace, assumedType string) (map[string]any, error) {
+	return envClient().ReadLegacyPayload(sourcePath, assumedNamespace, assumedType)
+}
+
+func (c *Client) ReadLegacyPayload(sourcePath, assumedNamespace, assumedType string) (map[string]any, error) {
+	if strings.TrimSpace(sourcePath) == "" {
+		return nil, errors.New("source path is required")
+	}
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, malformed(err)
+	}
+	if payload == nil {
+		return nil, malformed(errors.New("payload is not a JSON object"))
+	}
+	if _, ok := payload["payload"].(map[string]any); ok {
+		if _, ok := payload["version"]; ok {
+			return nil, fmt.Errorf("%s: already an envelope", sourcePath)
+		}
+	}
+	if err := Validate(assumedNamespace, assumedType, payload); err != nil {
+		return nil, c.observeValidation(err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,46 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadLegacyPayload(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0o600); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		return p
+	}
+
+	legacy := write("old.json", `{"id":"iv-1","phase":"planned","reason":"r","ts":1}`)
+	payload, err := ReadLegacyPayload(legacy, "interphase", "bead_phase")
+	if err != nil || payload["id"] != "iv-1" {
+		t.Fatalf("legacy read failed: %+v %v", payload, err)
+	}
+	p, _ := Path("interphase", "bead", "old")
+	if err := Write(p, "interphase", "bead_phase", "s", payload); err != nil {
+		t.Fatalf("migrating write failed: %v", err)
+	}
+	if _, err := ReadEnvelope(p); err != nil {
+		t.Fatalf("migrated envelope unreadable: %v", err)
+	}
+
+	bad := write("bad.json", `{"id":"iv-1","phase":"nope","reason":"r","ts":1}`)
+	if _, err := ReadLegacyPayload(bad, "interphase", "bead_phase"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	for _, data := range []string{`[1]`, `null`, `{"id":`} {
+		if _, err := ReadLegacyPayload(write("m.json", data), "custom", "any"); !errors.Is(err, ErrMalformed) {
+			t.Fatalf("%s: expected ErrMalformed, got %v", data, err)
+		}
+	}
+	if _, err := ReadLegacyPayload(p, "interphase", "bead_phase"); err == nil {
+		t.Fatal("expected an envelope to be rejected")
+	}
+}
@@ -27,19 +27,32 @@ interband_safe_key() {
     echo "$safe"
 }
 
+# Namespaces and channels must be single path segments that do not start
+# with '.', so no input can resolve outside the root.
+_interband_valid_segment() {
+    local name="${1:-}"
+    [[ -n "$name" && "$name" != .* && "$name" != */* && "$name" != *\\* ]]
+}
+
 interband_path() {
     local namespace="${1:-}" channel="${2:-}" key="${3:-}"
     [[ -n "$namespace" && -n "$channel" && -n "$key" ]] || return 1
+    _interband_valid_segment "$namespace" && _interband_valid_segment "$channel" || return 1
+    local safe
+    safe="$(interband_safe_key "$key")"
+    # Names starting with .interband are reserved for auxiliary files.
+    [[ "$safe" != .interband* ]] || return 1
     printf '%s/%s/%s/%s.json\n' \
         "$(interband_root)" \
         "$namespace" \
         "$channel" \
-        "$(interband_safe_key "$key")"
+        "$safe"
 }
 
 interband_channel_dir() {
     local namespace="${1:-}" channel="${2:-}"
     [[ -n "$namespace" && -n "$channel" ]] || return 1
+    _interband_valid_segment "$namespace" && _interband_valid_segment "$channel" || return 1
     printf '%s/%s/%s\n' "$(interband_root)" "$namespace" "$channel"
 }
 
@@ -143,9 +156,10 @@ interband_prune_channel() {
         if (( now - mtime > retention_secs )); then
             rm -f "$file" 2>/dev/null || true
         fi
-    done < <(find "$dir" -maxdepth 1 -type f -name '*.json' -print0 2>/dev/null)
+    done < <(find "$dir" -maxdepth 1 -type f -name '*.json' ! -name '.interband*' -print0 2>/dev/null)
 
-    # Enforce file count cap (keep newest files).
+    # Enforce file count cap (keep newest files; equal mtimes keep the
+    # lexically greatest names, matching the Go implementation).
     if (( max_files > 0 )); then
         local idx=0
         while IFS= read -r file; do
@@ -154,8 +168,8 @@ interband_prune_channel() {
                 rm -f "$file" 2>/dev/null || true
             fi
         done < <(
-            find "$dir" -maxdepth 1 -type f -name '*.json' -printf '%T@ %p\n' 2>/dev/null \
-                | sort -rn \
+            find "$dir" -maxdepth 1 -type f -name '*.json' ! -name '.interband*' -printf '%T@ %p\n' 2>/dev/null \
+                | sort -k1,1rn -k2,2r \
                 | awk '{$1=""; sub(/^ /,""); print}'
         )
     fi
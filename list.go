@@ -0,0 +1,223 @@
+package interband
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileError records a channel file that could not be read as an envelope.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string { return e.Path + ": " + e.Err.Error() }
+
+func (e FileError) Unwrap() error { return e.Err }
+
+// ListChannel reads every envelope in a channel, in SortEnvelopes order with
+// remaining ties broken by file key. Bundle files written by WriteBundle are
+// expanded in place. Files that fail to decode or validate are skipped and
+// reported in the returned FileError slice; the error is only set when the
+// channel itself cannot be read. A missing channel yields no envelopes.
+func ListChannel(namespace, channel string) ([]Envelope, []FileError, error) {
+	return envClient().ListChannel(namespace, channel)
+}
+
+func (c *Client) ListChannel(namespace, channel string) ([]Envelope, []FileError, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]keyedEnvelope, 0, len(entries))
+	var skipped []FileError
+	for _, entry := range entries {
+		envs, err := c.ReadBundle(entry.path)
+		if err != nil {
+			c.skip(entry.path, err)
+			skipped = append(skipped, FileError{Path: entry.path, Err: err})
+			continue
+		}
+		for idx, env := range envs {
+			if c.expired(env) {
+				continue
+			}
+			items = append(items, keyedEnvelope{key: entry.key, seq: idx, at: parseTimestamp(env.Timestamp), env: env})
+		}
+	}
+	sortKeyed(items)
+
+	out := make([]Envelope, len(items))
+	for idx, item := range items {
+		out[idx] = item.env
+	}
+	return out, skipped, nil
+}
+
+// ListChannelPage returns the envelopes of the files at positions [offset,
+// offset+limit) of a channel, ordered by file modification time and then file
+// name, and the channel's total file count. Only the directory is listed to
+// order the channel, so just the window's files are read. Modification order
+// matches ListChannel order for messages stamped when written, but not for
+// ones written with WriteEnvelope or WithTimestamp. A bundle file takes one
+// position and yields its items in ListChannel order. A file in the window
+// that cannot be read is reported to the skip handler and left out, as are
+// expired envelopes, so a page may come back short while total still counts
+// them.
+func ListChannelPage(namespace, channel string, offset, limit int) ([]Envelope, int, error) {
+	return envClient().ListChannelPage(namespace, channel, offset, limit)
+}
+
+func (c *Client) ListChannelPage(namespace, channel string, offset, limit int) ([]Envelope, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, errors.New("offset and limit must not be negative")
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].modTime.Equal(entries[j].modTime) {
+			return entries[i].modTime.Before(entries[j].modTime)
+		}
+		return entries[i].name < entries[j].name
+	})
+	total := len(entries)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	var out []Envelope
+	for _, entry := range entries[start:end] {
+		envs, err := c.readChannelFile(entry.path)
+		if err != nil {
+			c.skip(entry.path, err)
+			continue
+		}
+		items := make([]keyedEnvelope, 0, len(envs))
+		for idx, env := range envs {
+			if !c.expired(env) {
+				items = append(items, keyedEnvelope{seq: idx, at: parseTimestamp(env.Timestamp), env: env})
+			}
+		}
+		sortKeyed(items)
+		for _, item := range items {
+			out = append(out, item.env)
+		}
+	}
+	return out, total, nil
+}
+
+// LatestPerSession returns the newest envelope of each session in a channel,
+// keyed by session ID, for status boards. Newest is by envelope timestamp,
+// ties going to the envelope ListChannel orders last. Envelopes without a
+// session ID are left out, and unreadable files are skipped.
+func LatestPerSession(namespace, channel string) (map[string]Envelope, error) {
+	return envClient().LatestPerSession(namespace, channel)
+}
+
+func (c *Client) LatestPerSession(namespace, channel string) (map[string]Envelope, error) {
+	envs, _, err := c.ListChannel(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Envelope)
+	for _, env := range envs {
+		if env.SessionID != "" {
+			out[env.SessionID] = env
+		}
+	}
+	return out, nil
+}
+
+type keyedEnvelope struct {
+	key string
+	seq int // position within a bundle file
+	at  time.Time
+	env Envelope
+}
+
+// sortKeyed orders envelopes as SortEnvelopes does, breaking the remaining
+// ties by file key and then by position within a bundle.
+func sortKeyed(items []keyedEnvelope) {
+	sort.Slice(items, func(i, j int) bool {
+		if c := compareOrder(items[i].at, items[i].env.Key, items[j].at, items[j].env.Key); c != 0 {
+			return c < 0
+		}
+		if items[i].key != items[j].key {
+			return items[i].key < items[j].key
+		}
+		return items[i].seq < items[j].seq
+	})
+}
+
+// SortEnvelopes sorts envs into the order ListChannel, channel iterators,
+// ExportChannel, ReplayChannel, and each poll of Tail use: timestamp
+// ascending, with ties broken by the original Key lexicographically.
+// Unparseable timestamps sort first. The sort is stable, so envelopes that
+// still tie, such as ones without a recorded key, keep their relative order;
+// listings order those by file name. Query returns the reverse, newest first,
+// ListChannelPage orders files by modification time, and Watch and
+// WatchEvents report the changes of one scan in file name order.
+func SortEnvelopes(envs []Envelope) {
+	items := make([]keyedEnvelope, len(envs))
+	for idx, env := range envs {
+		items[idx] = keyedEnvelope{at: parseTimestamp(env.Timestamp), env: env}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return compareOrder(items[i].at, items[i].env.Key, items[j].at, items[j].env.Key) < 0
+	})
+	for idx, item := range items {
+		envs[idx] = item.env
+	}
+}
+
+// compareOrder is the comparator SortEnvelopes and the listings share.
+func compareOrder(at1 time.Time, key1 string, at2 time.Time, key2 string) int {
+	if c := at1.Compare(at2); c != 0 {
+		return c
+	}
+	return strings.Compare(key1, key2)
+}
+
+// parseTimestamp parses an envelope timestamp, returning the zero time when it
+// cannot be parsed.
+func parseTimestamp(raw string) time.Time {
+	t, _ := parseEnvelopeTime(raw)
+	return t
+}
+
+// ReadLatest returns the valid envelope with the newest timestamp in a
+// channel, the one ListChannel would order last.
+// Only envelope headers are read to find it. An empty channel, or one with no
+// valid envelopes, returns an error matching ErrNotFound.
+func ReadLatest(namespace, channel string) (Envelope, error) {
+	return envClient().ReadLatest(namespace, channel)
+}
+
+func (c *Client) ReadLatest(namespace, channel string) (Envelope, error) {
+	it, err := c.NewChannelIterator(namespace, channel)
+	if err != nil {
+		return Envelope{}, err
+	}
+	for idx := len(it.entries) - 1; idx >= 0; idx-- {
+		env, ok, err := it.read(it.entries[idx])
+		if err != nil {
+			c.skip(it.entries[idx].path, err)
+		}
+		if ok {
+			return env, nil
+		}
+	}
+	return Envelope{}, fmt.Errorf("%w: no envelopes in %s/%s", ErrNotFound, namespace, channel)
+}
@@ -0,0 +1,224 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeAt(t *testing.T, namespace, channel, key string, at time.Time, payload map[string]any) string {
+	t.Helper()
+	p, err := Path(namespace, channel, key)
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, namespace, "anything", "sess", payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	env, err := ReadEnvelope(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	env.Timestamp = at.UTC().Format(time.RFC3339Nano)
+	raw, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if err := os.WriteFile(p, raw, 0o644); err != nil {
+		t.Fatalf("rewrite failed: %v", err)
+	}
+	return p
+}
+
+func TestListChannelOrdersAndSkips(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeAt(t, "custom", "events", "late", base.Add(2*time.Second), map[string]any{"n": 3})
+	writeAt(t, "custom", "events", "b-tie", base, map[string]any{"n": 2})
+	writeAt(t, "custom", "events", "a-tie", base, map[string]any{"n": 1})
+
+	dir, _ := ChannelDir("custom", "events")
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write bad failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".interband-tmp.abc"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write tmp failed: %v", err)
+	}
+
+	envs, skipped, err := ListChannel("custom", "events")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(envs) != 3 {
+		t.Fatalf("expected 3 envelopes, got %d", len(envs))
+	}
+	for idx, want := range []float64{1, 2, 3} {
+		if got := envs[idx].Payload["n"]; got != want {
+			t.Fatalf("position %d: expected n=%v, got %v", idx, want, got)
+		}
+	}
+	if len(skipped) != 1 || skipped[0].Path != bad {
+		t.Fatalf("expected bad.json to be skipped, got %+v", skipped)
+	}
+
+	envs, skipped, err = ListChannel("custom", "missing")
+	if err != nil || len(envs) != 0 || len(skipped) != 0 {
+		t.Fatalf("expected empty listing for missing channel, envs=%v skipped=%v err=%v", envs, skipped, err)
+	}
+}
+
+func TestReadLatest(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	if _, err := ReadLatest("interphase", "bead"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for empty channel, got %v", err)
+	}
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	newest := writeAt(t, "custom", "events", "a-newest", base.Add(time.Hour), map[string]any{"n": 2})
+	writeAt(t, "custom", "events", "z-older", base, map[string]any{"n": 1})
+	// The newest file by mod-time carries the oldest timestamp.
+	writeAt(t, "custom", "events", "m-oldest", base.Add(-time.Hour), map[string]any{"n": 0})
+
+	env, err := ReadLatest("custom", "events")
+	if err != nil {
+		t.Fatalf("read latest failed: %v", err)
+	}
+	if env.Payload["n"] != float64(2) {
+		t.Fatalf("expected newest by timestamp, got %v", env.Payload)
+	}
+
+	if err := os.WriteFile(newest, []byte(`{"version":"1.0.0","timestamp":"2030-01-01T00:00:00Z"}`), 0o644); err != nil {
+		t.Fatalf("corrupt failed: %v", err)
+	}
+	env, err = ReadLatest("custom", "events")
+	if err != nil || env.Payload["n"] != float64(1) {
+		t.Fatalf("expected fallback to next valid envelope, got %v %v", env.Payload, err)
+	}
+}
+
+func TestListChannelPage(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Keys sort opposite to modification times, so ordering must come from
+	// the directory metadata.
+	for i := range 5 {
+		p := writeAt(t, "custom", "events", fmt.Sprintf("k%d", 9-i), base.Add(time.Duration(i)*time.Minute), map[string]any{"n": i})
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(p, at, at); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	page, total, err := ListChannelPage("custom", "events", 1, 2)
+	if err != nil || total != 5 || len(page) != 2 {
+		t.Fatalf("unexpected page: %+v total=%d err=%v", page, total, err)
+	}
+	if page[0].Payload["n"] != 1.0 || page[1].Payload["n"] != 2.0 {
+		t.Fatalf("page out of order: %+v", page)
+	}
+	if page, total, _ := ListChannelPage("custom", "events", 4, 10); len(page) != 1 || total != 5 {
+		t.Fatalf("expected a short last page, got %d of %d", len(page), total)
+	}
+	if page, _, _ := ListChannelPage("custom", "events", 10, 10); len(page) != 0 {
+		t.Fatalf("expected an empty page past the end, got %+v", page)
+	}
+	if _, _, err := ListChannelPage("custom", "events", -1, 1); err == nil {
+		t.Fatal("expected a negative offset to fail")
+	}
+	if page, total, err := ListChannelPage("custom", "missing", 0, 10); err != nil || total != 0 || len(page) != 0 {
+		t.Fatalf("missing channel should be empty: %+v %d %v", page, total, err)
+	}
+}
+
+func TestLatestPerSession(t *testing.T) {
+	c, _ := newMemClient(t)
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for idx, w := range []struct {
+		key, session string
+		at           time.Duration
+	}{
+		{"a1", "a", 0}, {"a2", "a", 2 * time.Second}, {"a0", "a", time.Second},
+		{"b1", "b", time.Second}, {"none", "", 3 * time.Second},
+	} {
+		p, _ := c.Path("custom", "status", w.key)
+		env := Envelope{Version: "1.0.0", Namespace: "custom", Type: "status", SessionID: w.session,
+			Timestamp: base.Add(w.at).Format(time.RFC3339Nano), Payload: map[string]any{"n": float64(idx)}}
+		if err := c.WriteEnvelope(p, env); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	got, err := c.LatestPerSession("custom", "status")
+	if err != nil {
+		t.Fatalf("latest per session failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected sessions a and b, got %v", got)
+	}
+	if got["a"].Payload["n"] != 1.0 || got["b"].Payload["n"] != 3.0 {
+		t.Fatalf("unexpected latest envelopes: %v", got)
+	}
+	if empty, err := c.LatestPerSession("custom", "missing"); err != nil || len(empty) != 0 {
+		t.Fatalf("missing channel: %v, %v", empty, err)
+	}
+}
+
+func TestOrderingTieBreak(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// "a!" is stored as a_.json, which sorts after a-.json, so the original
+	// key must decide. Path-only envelopes have no key and tie by file name.
+	for key, payload := range map[string]string{"a-": "dash", "a!": "bang"} {
+		if err := WriteKey("custom", "events", key, "anything", "s", map[string]any{"v": payload}, WithTimestamp(at)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	for _, key := range []string{"d", "c"} {
+		p, _ := Path("custom", "events", key)
+		if err := Write(p, "custom", "anything", "s", map[string]any{"v": key}, WithTimestamp(at)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	writeAt(t, "custom", "events", "0-early", at.Add(-time.Second), map[string]any{"v": "early"})
+
+	want := []string{"early", "c", "d", "bang", "dash"}
+	order := func(envs []Envelope) []string {
+		var out []string
+		for _, env := range envs {
+			out = append(out, env.Payload["v"].(string))
+		}
+		return out
+	}
+	envs, _, err := ListChannel("custom", "events")
+	if err != nil || fmt.Sprint(order(envs)) != fmt.Sprint(want) {
+		t.Fatalf("ListChannel order %v, want %v (%v)", order(envs), want, err)
+	}
+
+	it, _ := NewChannelIterator("custom", "events")
+	var iterated []Envelope
+	for it.Next() {
+		iterated = append(iterated, it.Envelope())
+	}
+	if fmt.Sprint(order(iterated)) != fmt.Sprint(want) {
+		t.Fatalf("iterator order %v, want %v", order(iterated), want)
+	}
+
+	resorted := append([]Envelope(nil), envs...)
+	SortEnvelopes(resorted)
+	if fmt.Sprint(order(resorted)) != fmt.Sprint(want) {
+		t.Fatalf("SortEnvelopes changed ListChannel order: %v", order(resorted))
+	}
+	reversed := []Envelope{envs[4], envs[3], envs[0]}
+	SortEnvelopes(reversed)
+	if got := order(reversed); fmt.Sprint(got) != "[early bang dash]" {
+		t.Fatalf("SortEnvelopes tie-break by key failed: %v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package interband
+
+// WithChannelLock runs fn while holding an exclusive advisory lock on the
+// channel, shared across processes (flock on Unix, LockFileEx on Windows).
+// Use it to make read-modify-write sequences on a channel safe. The lock is
+// only advisory: writers that do not take it are not blocked. Backends that
+// do not implement Locker run fn without a lock. On platforms without either
+// primitive, such as plan9 and js, the default backend cannot lock, and
+// WithChannelLock fails with an error matching errors.ErrUnsupported without
+// running fn; so do Update, Append, PruneChannel, and the other locked
+// operations. A read-only client fails with ErrReadOnly without running fn.
+func WithChannelLock(namespace, channel string, fn func() error) error {
+	return envClient().WithChannelLock(namespace, channel, fn)
+}
+
+func (c *Client) WithChannelLock(namespace, channel string, fn func() error) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return err
+	}
+	locker, ok := c.backend().(Locker)
+	if !ok {
+		return fn()
+	}
+	unlock, err := locker.Lock(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package interband
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Platforms without flock or LockFileEx cannot lock across processes, so
+// locking fails rather than silently providing no mutual exclusion.
+func lockFile(*os.File) error {
+	return fmt.Errorf("interband: channel locks on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
+
+func unlockFile(*os.File) error { return nil }
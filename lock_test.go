@@ -0,0 +1,51 @@
+package interband
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWithChannelLockSerializesReadModifyWrite(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, err := Path("custom", "counter", "total")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "counter", "s", map[string]any{"n": 0}); err != nil {
+		t.Fatalf("seed write failed: %v", err)
+	}
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- WithChannelLock("custom", "counter", func() error {
+				payload, err := ReadPayload(p)
+				if err != nil {
+					return err
+				}
+				n := payload["n"].(float64)
+				return Write(p, "custom", "counter", strconv.Itoa(i), map[string]any{"n": n + 1})
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("locked update failed: %v", err)
+		}
+	}
+
+	payload, err := ReadPayload(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if payload["n"] != float64(workers) {
+		t.Fatalf("expected %d increments, got %v", workers, payload["n"])
+	}
+}
@@ -0,0 +1,25 @@
+package interband
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	logger.Store(slog.New(slog.DiscardHandler))
+}
+
+// SetLogger sets the logger used for non-fatal warnings. A nil logger
+// discards them, which is the default.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	logger.Store(l)
+}
+
+func logWarn(msg string, args ...any) {
+	logger.Load().Warn(msg, args...)
+}
@@ -0,0 +1,321 @@
+package interband
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SegmentExt is the extension of the JSON Lines segments LogWriter writes.
+const SegmentExt = ".jsonl"
+
+const segmentPrefix = "segment-"
+
+// DefaultSegmentBytes is the size at which a LogWriter given no limit starts a
+// new segment.
+const DefaultSegmentBytes = 4 << 20
+
+// LogWriter appends messages to a channel as JSON Lines, one envelope per
+// line, in numbered segment files such as segment-000001.jsonl. It suits
+// channels too busy for a file per message; read them back with ReadLog and
+// expire them whole with PruneLog. Segments sit beside per-key files, which
+// listings, iteration, and PruneChannel keep handling on their own. A
+// LogWriter is safe for concurrent use; its writes are serialized.
+type LogWriter struct {
+	c         *Client
+	namespace string
+	channel   string
+	dir       string
+	maxBytes  int64
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	enc    *json.Encoder
+	seq    uint64
+	size   int64
+	out    io.WriteCloser
+	closed bool
+}
+
+// NewLogWriter returns a LogWriter for namespace/channel that starts a new
+// segment before a line would take the current one past maxSegmentBytes. A
+// non-positive maxSegmentBytes means DefaultSegmentBytes. Writing resumes in
+// the channel's newest segment. The backend must implement Appender.
+func NewLogWriter(namespace, channel string, maxSegmentBytes int64) (*LogWriter, error) {
+	return envClient().NewLogWriter(namespace, channel, maxSegmentBytes)
+}
+
+func (c *Client) NewLogWriter(namespace, channel string, maxSegmentBytes int64) (*LogWriter, error) {
+	if err := c.writable(); err != nil {
+		return nil, err
+	}
+	if _, ok := c.backend().(Appender); !ok {
+		return nil, errors.New("interband: backend does not support appending")
+	}
+	if strings.TrimSpace(namespace) == "" || strings.TrimSpace(channel) == "" {
+		return nil, errors.New("namespace and channel are required")
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	if maxSegmentBytes <= 0 {
+		maxSegmentBytes = DefaultSegmentBytes
+	}
+	w := &LogWriter{c: c, namespace: namespace, channel: channel, dir: dir, maxBytes: maxSegmentBytes}
+	w.enc = json.NewEncoder(&w.buf)
+	w.enc.SetEscapeHTML(false)
+	return w, nil
+}
+
+// Write appends a message as Write would stamp and validate it. A single
+// message larger than the segment limit gets a segment of its own.
+func (w *LogWriter) Write(typ, sessionID string, payload map[string]any, opts ...WriteOption) error {
+	if err := w.c.writable(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("interband: log writer is closed")
+	}
+	if w.out == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	p := filepath.Join(w.dir, segmentName(w.seq))
+	env, err := w.c.newEnvelopeWith(p, w.namespace, typ, sessionID, payload, opts)
+	if err != nil {
+		return err
+	}
+	if err := ValidateEnvelope(env); err != nil {
+		return w.c.observeValidation(err)
+	}
+	w.buf.Reset()
+	if err := w.enc.Encode(env); err != nil {
+		return err
+	}
+	data := w.buf.Bytes()
+	if err := checkSize(p, int64(len(data)), w.c.cfg.MaxPayloadBytes); err != nil {
+		return err
+	}
+	if w.size > 0 && w.size+int64(len(data)) > w.maxBytes {
+		if err := w.out.Close(); err != nil {
+			return err
+		}
+		w.out = nil
+		w.seq++
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	n, err := w.out.Write(data)
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+	w.c.observe(func(o Observer) { o.OnWrite(env.Namespace, env.Type, len(data)) })
+	return nil
+}
+
+// Close closes the current segment. Later writes fail.
+func (w *LogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.out == nil {
+		return nil
+	}
+	err := w.out.Close()
+	w.out = nil
+	return err
+}
+
+// open opens segment w.seq for appending, first picking the channel's newest
+// segment when no segment has been chosen. w.mu must be held.
+func (w *LogWriter) open() error {
+	if w.seq == 0 {
+		segments, err := w.c.readSegments(w.dir)
+		if err != nil {
+			return err
+		}
+		w.seq = 1
+		if len(segments) > 0 {
+			w.seq = segments[len(segments)-1].seq
+		}
+	}
+	p := filepath.Join(w.dir, segmentName(w.seq))
+	out, err := w.c.backend().(Appender).OpenAppend(p)
+	if err != nil {
+		return err
+	}
+	w.out, w.size = out, 0
+	// Another writer may have appended to the segment already.
+	if info, err := w.c.backend().Stat(p); err == nil {
+		w.size = info.Size()
+	}
+	return nil
+}
+
+// ReadLog calls fn with each message a LogWriter wrote to the channel, in
+// segment order and then line order. A line that fails to decode or validate
+// is reported to the skip handler and skipped, and an unterminated last line,
+// which may still be being written, is ignored. Expired messages are skipped
+// when the client honors expiry. An error from fn stops the read and is
+// returned. A channel without segments reads as empty.
+func ReadLog(namespace, channel string, fn func(Envelope) error) error {
+	return envClient().ReadLog(namespace, channel, fn)
+}
+
+func (c *Client) ReadLog(namespace, channel string, fn func(Envelope) error) error {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return err
+	}
+	segments, err := c.readSegments(dir)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		data, err := c.backend().ReadFile(seg.path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // pruned since listing
+		} else if err != nil {
+			return err
+		}
+		for len(data) > 0 {
+			line, rest, complete := bytes.Cut(data, []byte("\n"))
+			if !complete {
+				break
+			}
+			data = rest
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			env, err := c.UnmarshalEnvelope(line)
+			if err != nil {
+				c.skip(seg.path, err)
+				continue
+			}
+			if c.expired(env) {
+				continue
+			}
+			if err := fn(env); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PruneLog removes whole segments from a channel: those last written longer
+// ago than the channel's RetentionSeconds, and the oldest beyond MaxFiles
+// segments. The newest segment, which writers append to, is always kept.
+// Pruning holds the channel lock. It returns the number of segments removed;
+// removal failures are returned joined.
+func PruneLog(namespace, channel string) (int, error) {
+	return envClient().PruneLog(namespace, channel)
+}
+
+func (c *Client) PruneLog(namespace, channel string) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.backend().Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	removed := 0
+	var errs []error
+	err = c.WithChannelLock(namespace, channel, func() error {
+		segments, err := c.readSegments(dir)
+		if err != nil || len(segments) < 2 {
+			return err
+		}
+		older := segments[:len(segments)-1]
+		cutoff := c.now().Add(-c.retention(namespace, channel))
+		// Segments are listed oldest first, so the overflow is a prefix.
+		overflow := 0
+		if maxFiles := c.MaxFiles(namespace, channel); maxFiles > 0 {
+			overflow = max(len(segments)-maxFiles, 0)
+		}
+		for idx, seg := range older {
+			if idx >= overflow && !seg.modTime.Before(cutoff) {
+				continue
+			}
+			if err := c.backend().Remove(seg.path); err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	return removed, errors.Join(errs...)
+}
+
+type logSegment struct {
+	seq     uint64
+	path    string
+	modTime time.Time
+}
+
+// readSegments lists dir's log segments in sequence order.
+func (c *Client) readSegments(dir string) ([]logSegment, error) {
+	entries, err := c.backend().ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []logSegment
+	for _, entry := range entries {
+		seq, ok := segmentSeq(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, logSegment{seq: seq, path: filepath.Join(dir, entry.Name()), modTime: info.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out, nil
+}
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%s%06d%s", segmentPrefix, seq, SegmentExt)
+}
+
+// segmentSeq parses a segment file name, rejecting other files.
+func segmentSeq(name string) (uint64, bool) {
+	digits, ok := strings.CutPrefix(name, segmentPrefix)
+	if !ok {
+		return 0, false
+	}
+	if digits, ok = strings.CutSuffix(digits, SegmentExt); !ok {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(digits, 10, 64)
+	return seq, err == nil && seq > 0
+}
@@ -0,0 +1,135 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogWriterRotatesAndReads(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	w, err := NewLogWriter("custom", "firehose", 300)
+	if err != nil {
+		t.Fatalf("new log writer failed: %v", err)
+	}
+	for i := range 10 {
+		if err := w.Write("tick", "s", map[string]any{"n": i}); err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if err := w.Write("tick", "s", map[string]any{}); err == nil {
+		t.Fatal("expected write after close to fail")
+	}
+
+	dir, _ := ChannelDir("custom", "firehose")
+	segments, _ := filepath.Glob(filepath.Join(dir, "segment-*.jsonl"))
+	if len(segments) < 3 {
+		t.Fatalf("expected rotation into several segments, got %v", segments)
+	}
+	for _, seg := range segments {
+		if info, _ := os.Stat(seg); info.Size() > 300 {
+			t.Fatalf("%s exceeds the segment limit: %d bytes", seg, info.Size())
+		}
+	}
+	if envs, _, _ := ListChannel("custom", "firehose"); len(envs) != 0 {
+		t.Fatalf("segments leaked into ListChannel: %+v", envs)
+	}
+
+	// A crash mid-line leaves an unterminated tail; a bad line is skipped.
+	last := segments[len(segments)-1]
+	f, _ := os.OpenFile(last, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.WriteString("not json\n{\"version\":")
+	_ = f.Close()
+	var skipped []string
+	SetSkipHandler(func(path string, err error) { skipped = append(skipped, path) })
+	defer SetSkipHandler(nil)
+
+	var got []float64
+	err = ReadLog("custom", "firehose", func(env Envelope) error {
+		got = append(got, env.Payload["n"].(float64))
+		return nil
+	})
+	if err != nil || len(got) != 10 {
+		t.Fatalf("expected 10 messages, got %v %v", got, err)
+	}
+	for i, n := range got {
+		if int(n) != i {
+			t.Fatalf("messages out of order: %v", got)
+		}
+	}
+	if len(skipped) != 1 || skipped[0] != last {
+		t.Fatalf("expected the bad line reported once, got %v", skipped)
+	}
+
+	stop := errors.New("stop")
+	if err := ReadLog("custom", "firehose", func(Envelope) error { return stop }); err != stop {
+		t.Fatalf("expected fn error returned, got %v", err)
+	}
+
+	// Reopening resumes in the newest segment.
+	w, _ = NewLogWriter("custom", "firehose", 300)
+	if err := w.Write("tick", "s", map[string]any{"n": 10}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	_ = w.Close()
+	if again, _ := filepath.Glob(filepath.Join(dir, "segment-*.jsonl")); len(again) > len(segments)+1 {
+		t.Fatalf("reopen should not skip segments: %v", again)
+	}
+}
+
+func TestPruneLog(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_RETENTION_SECS", "3600")
+	t.Setenv("INTERBAND_MAX_FILES", "3")
+
+	w, _ := NewLogWriter("custom", "firehose", 1)
+	for i := range 5 {
+		if err := w.Write("tick", "s", map[string]any{"n": i}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	_ = w.Close()
+	dir, _ := ChannelDir("custom", "firehose")
+	old := time.Now().Add(-2 * time.Hour)
+	for _, seq := range []uint64{4, 5} {
+		p := filepath.Join(dir, segmentName(seq))
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	n, err := PruneLog("custom", "firehose")
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 segments pruned, got %d %v", n, err)
+	}
+	left, _ := filepath.Glob(filepath.Join(dir, "segment-*.jsonl"))
+	if len(left) != 2 || filepath.Base(left[1]) != segmentName(5) {
+		t.Fatalf("expected segments 3 and 5 kept, got %v", left)
+	}
+	if n, err := PruneLog("custom", "missing"); n != 0 || err != nil {
+		t.Fatalf("missing channel should be a no-op: %d %v", n, err)
+	}
+}
+
+func TestLogWriterMemBackend(t *testing.T) {
+	c, _ := newMemClient(t)
+	w, err := c.NewLogWriter("custom", "firehose", 0)
+	if err != nil {
+		t.Fatalf("new log writer failed: %v", err)
+	}
+	for i := range 3 {
+		if err := w.Write("tick", "s", map[string]any{"n": i}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	count := 0
+	if err := c.ReadLog("custom", "firehose", func(Envelope) error { count++; return nil }); err != nil || count != 3 {
+		t.Fatalf("expected 3 messages, got %d %v", count, err)
+	}
+}
@@ -0,0 +1,125 @@
+package interband
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MigrateChannel rewrites every envelope in a channel whose version differs
+// from targetVersion. Each such envelope is passed to transform (nil keeps it
+// as is), stamped with targetVersion, validated, and written back atomically,
+// keeping its timestamp, compression, and bundle layout. Envelopes already at
+// targetVersion are left alone, so repeated runs are no-ops. The migration
+// holds the channel lock; per-file failures are returned joined as FileErrors
+// without stopping the rest. The count is the number of files rewritten.
+func MigrateChannel(namespace, channel, targetVersion string, transform func(Envelope) (Envelope, error)) (int, error) {
+	return envClient().MigrateChannel(namespace, channel, targetVersion, transform)
+}
+
+func (c *Client) MigrateChannel(namespace, channel, targetVersion string, transform func(Envelope) (Envelope, error)) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckVersion(targetVersion, false); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	var errs []error
+	err = c.WithChannelLock(namespace, channel, func() error {
+		entries, err := c.readChannelEntries(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			changed, err := c.migrateFile(entry.path, targetVersion, transform)
+			if err != nil {
+				errs = append(errs, FileError{Path: entry.path, Err: err})
+				continue
+			}
+			if changed {
+				migrated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return migrated, err
+	}
+	return migrated, errors.Join(errs...)
+}
+
+// migrateFile migrates one channel file, reporting whether it was rewritten.
+func (c *Client) migrateFile(sourcePath, targetVersion string, transform func(Envelope) (Envelope, error)) (bool, error) {
+	raw, err := c.backend().ReadFile(sourcePath)
+	if err != nil {
+		return false, err
+	}
+	data, err := maybeGunzip(raw, sourcePath, c.cfg.MaxPayloadBytes)
+	if err != nil {
+		return false, err
+	}
+	bundle := isBundle(data)
+	var envs []Envelope
+	if bundle {
+		err = json.Unmarshal(data, &envs)
+	} else {
+		var env Envelope
+		err = json.Unmarshal(data, &env)
+		envs = []Envelope{env}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for idx, env := range envs {
+		if env.Version == targetVersion {
+			continue
+		}
+		if transform != nil {
+			if env, err = transform(env); err != nil {
+				return false, err
+			}
+		}
+		env.Version = targetVersion
+		if err := ValidateEnvelope(env); err != nil {
+			return false, err
+		}
+		envs[idx] = env
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	var out []byte
+	if bundle {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(envs); err != nil {
+			return false, err
+		}
+		out = buf.Bytes()
+	} else if out, err = encodeEnvelope(envs[0]); err != nil {
+		return false, err
+	}
+	if err := checkSize(sourcePath, int64(len(out)), c.cfg.MaxPayloadBytes); err != nil {
+		return false, err
+	}
+	if strings.HasSuffix(sourcePath, CompressedExt) || !bytes.Equal(raw, data) {
+		if out, err = gzipBytes(out); err != nil {
+			return false, fmt.Errorf("compress: %w", err)
+		}
+	}
+	return true, c.backend().WriteAtomic(context.Background(), sourcePath, out)
+}
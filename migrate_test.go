@@ -0,0 +1,64 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMigrateChannel(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PROTOCOL_VERSION", "1.0.0")
+
+	payload := map[string]any{"layer": "l", "icon": "i", "text": "t", "ts": "now", "priority": 1}
+	old, _ := Path("interlock", "coordination", "old")
+	if err := Write(old, "interlock", "coordination_signal", "s", payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	gz, _ := Path("interlock", "coordination", "gz")
+	if err := WriteCompressed(gz+CompressedExt, "interlock", "coordination_signal", "s", payload); err != nil {
+		t.Fatalf("compressed write failed: %v", err)
+	}
+	before, _ := ReadEnvelope(old)
+
+	tag := func(env Envelope) (Envelope, error) {
+		env.Payload["migrated"] = true
+		return env, nil
+	}
+	n, err := MigrateChannel("interlock", "coordination", "1.1.0", tag)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 migrated files, got %d %v", n, err)
+	}
+	for _, p := range []string{old, gz + CompressedExt} {
+		env, err := ReadEnvelope(p)
+		if err != nil {
+			t.Fatalf("read %s failed: %v", p, err)
+		}
+		if env.Version != "1.1.0" || env.Payload["migrated"] != true {
+			t.Fatalf("envelope not migrated: %+v", env)
+		}
+	}
+	if after, _ := ReadEnvelope(old); after.Timestamp != before.Timestamp {
+		t.Fatalf("timestamp changed: %s -> %s", before.Timestamp, after.Timestamp)
+	}
+
+	n, err = MigrateChannel("interlock", "coordination", "1.1.0", tag)
+	if err != nil || n != 0 {
+		t.Fatalf("second run should be a no-op, got %d %v", n, err)
+	}
+
+	if _, err := MigrateChannel("interlock", "coordination", "2.0.0", nil); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected unsupported target to fail, got %v", err)
+	}
+	bad := func(env Envelope) (Envelope, error) {
+		env.Payload = nil
+		return env, nil
+	}
+	n, err = MigrateChannel("interlock", "coordination", "1.2.0", bad)
+	var fe FileError
+	if n != 0 || !errors.As(err, &fe) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected per-file validation errors, got %d %v", n, err)
+	}
+	if env, _ := ReadEnvelope(old); env.Version != "1.1.0" {
+		t.Fatalf("failed migration should leave the file alone, got %s", env.Version)
+	}
+}
@@ -0,0 +1,78 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+)
+
+// MoveKey moves key's message from one channel to another, replacing any
+// message stored under key at the destination, for state transitions such
+// as pending to active. The file is moved as is, so its envelope (namespace
+// field included), timestamp, and compression are unchanged. On backends
+// that implement Renamer it is renamed atomically; across filesystems, or on
+// other backends, it is copied and the source removed. The destination's other
+// form, plain or compressed, is removed only once the message has landed. The
+// move holds the destination channel lock. A missing source fails with
+// ErrNotFound.
+func MoveKey(srcNamespace, srcChannel, dstNamespace, dstChannel, key string) error {
+	return envClient().MoveKey(srcNamespace, srcChannel, dstNamespace, dstChannel, key)
+}
+
+func (c *Client) MoveKey(srcNamespace, srcChannel, dstNamespace, dstChannel, key string) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	src, err := c.Path(srcNamespace, srcChannel, key)
+	if err != nil {
+		return err
+	}
+	dst, err := c.Path(dstNamespace, dstChannel, key)
+	if err != nil {
+		return err
+	}
+	return c.WithChannelLock(dstNamespace, dstChannel, func() error {
+		_, srcFile, err := c.statKeyFile(src)
+		if err != nil {
+			return err
+		}
+		ext := strings.TrimPrefix(srcFile, src)
+		dstFile := dst + ext
+		if srcFile == dstFile {
+			return nil
+		}
+		if err := c.moveFile(srcFile, dstFile); err != nil {
+			return err
+		}
+		// Only now that the message has landed, drop the other form of the
+		// destination key so it cannot shadow the moved message.
+		other := dst + CompressedExt
+		if ext != "" {
+			other = dst
+		}
+		if err := c.backend().Remove(other); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+}
+
+// moveFile renames srcFile to dstFile, or copies it and removes the source
+// where the backend cannot rename across the two.
+func (c *Client) moveFile(srcFile, dstFile string) error {
+	if r, ok := c.backend().(Renamer); ok {
+		err := r.Rename(srcFile, dstFile)
+		if err == nil || !isCrossDevice(err) {
+			return err
+		}
+	}
+	data, err := c.backend().ReadFile(srcFile)
+	if err != nil {
+		return err
+	}
+	if err := c.backend().WriteAtomic(context.Background(), dstFile, data); err != nil {
+		return err
+	}
+	return c.backend().Remove(srcFile)
+}
@@ -0,0 +1,125 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMoveKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	c := NewClient(cfg)
+
+	src, err := c.Path("interphase", "pending", "iv-1")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := c.Write(src, "interphase", "anything", "s", map[string]any{"k": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	before, err := c.ReadEnvelope(src)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(src, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	if err := c.MoveKey("interphase", "pending", "interphase", "active", "iv-1"); err != nil {
+		t.Fatalf("move failed: %v", err)
+	}
+	if ok, _ := c.Exists("interphase", "pending", "iv-1"); ok {
+		t.Fatal("source still exists after move")
+	}
+	dst, _ := c.Path("interphase", "active", "iv-1")
+	after, err := c.ReadEnvelope(dst)
+	if err != nil {
+		t.Fatalf("read moved envelope: %v", err)
+	}
+	if after.Timestamp != before.Timestamp {
+		t.Fatalf("timestamp changed: %s -> %s", before.Timestamp, after.Timestamp)
+	}
+	if info, err := os.Stat(dst); err != nil || !info.ModTime().Equal(old) {
+		t.Fatalf("rename should keep the modification time, got %v, %v", info, err)
+	}
+
+	if err := c.MoveKey("interphase", "pending", "interphase", "active", "iv-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing source, got %v", err)
+	}
+	gz, _ := c.Path("interphase", "pending", "iv-2")
+	if err := c.WriteCompressed(gz+CompressedExt, "interphase", "anything", "s", map[string]any{"k": 2}); err != nil {
+		t.Fatalf("compressed write failed: %v", err)
+	}
+	if err := c.MoveKey("interphase", "pending", "interphase", "active", "iv-2"); err != nil {
+		t.Fatalf("compressed move failed: %v", err)
+	}
+	moved, _ := c.Path("interphase", "active", "iv-2")
+	if _, err := os.Stat(moved + CompressedExt); err != nil {
+		t.Fatalf("compressed file should stay compressed: %v", err)
+	}
+
+	if err := c.MoveKey("interphase", "active", "interphase", "../escape", "iv-1"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for a bad destination, got %v", err)
+	}
+}
+
+// copyOnlyBackend hides MemBackend's Rename to exercise the copy fallback.
+type copyOnlyBackend struct{ Backend }
+
+func TestMoveKeyCopyFallback(t *testing.T) {
+	mem := NewMemBackend()
+	cfg := DefaultConfig()
+	cfg.Root = "/mem"
+	cfg.Backend = copyOnlyBackend{mem}
+	c := NewClient(cfg)
+
+	for _, ch := range []string{"pending", "active"} {
+		p, _ := c.Path("custom", ch, "job")
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{"from": ch}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := c.MoveKey("custom", "pending", "custom", "active", "job"); err != nil {
+		t.Fatalf("move failed: %v", err)
+	}
+	p, _ := c.Path("custom", "active", "job")
+	env, err := c.ReadEnvelope(p)
+	if err != nil || env.Payload["from"] != "pending" {
+		t.Fatalf("destination should hold the moved message, got %v, %v", env.Payload, err)
+	}
+	if ok, _ := c.Exists("custom", "pending", "job"); ok {
+		t.Fatal("source still exists after move")
+	}
+}
+
+// failingRenameBackend fails every Rename with an error MoveKey must not
+// fall back from.
+type failingRenameBackend struct{ *MemBackend }
+
+func (failingRenameBackend) Rename(string, string) error { return os.ErrPermission }
+
+func TestMoveKeyFailureKeepsDestination(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = "/mem"
+	cfg.Backend = failingRenameBackend{NewMemBackend()}
+	c := NewClient(cfg)
+
+	src, _ := c.Path("custom", "pending", "job")
+	if err := c.WriteCompressed(src+CompressedExt, "custom", "anything", "s", map[string]any{"from": "pending"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	dst, _ := c.Path("custom", "active", "job")
+	if err := c.Write(dst, "custom", "anything", "s", map[string]any{"from": "active"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := c.MoveKey("custom", "pending", "custom", "active", "job"); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("expected the rename error, got %v", err)
+	}
+	env, err := c.ReadEnvelope(dst)
+	if err != nil || env.Payload["from"] != "active" {
+		t.Fatalf("destination's message should survive a failed move, got %v, %v", env.Payload, err)
+	}
+}
@@ -0,0 +1,104 @@
+package interband
+
+import (
+	"maps"
+	"sync"
+)
+
+// NamespaceSpec gathers the defaults for a namespace a team owns.
+type NamespaceSpec struct {
+	Namespace string
+	// RetentionSeconds and MaxFiles are the defaults for channels without an
+	// entry in Channels. Zero keeps the package fallback.
+	RetentionSeconds int
+	MaxFiles         int
+	// Channels overrides the defaults for individual channels. Each entry's
+	// Namespace is ignored; zero limits fall back to the namespace defaults.
+	Channels []ChannelDefault
+	// Types lists the namespace's payload types with their validators. A nil
+	// validator accepts any object, subject to built-in rules. When Types is
+	// non-empty, payloads of any other type fail validation.
+	Types map[string]PayloadValidator
+	// SessionID, if set, supplies the session ID for writes to the namespace
+	// that pass an empty one. It takes precedence over AutoSessionID.
+	SessionID func() string
+}
+
+var (
+	namespacesMu sync.RWMutex
+	namespaces   = builtinNamespaces()
+)
+
+// builtinNamespaces pre-registers the built-in channel defaults.
+func builtinNamespaces() map[string]NamespaceSpec {
+	specs := map[string]NamespaceSpec{}
+	for _, d := range channelDefaults {
+		spec := specs[d.Namespace]
+		spec.Namespace = d.Namespace
+		spec.Channels = append(spec.Channels, d)
+		specs[d.Namespace] = spec
+	}
+	return specs
+}
+
+// RegisterNamespace installs spec, replacing any earlier registration for its
+// namespace, including the built-in channel defaults. It is safe to call from
+// init and concurrently with reads and writes. Per-channel environment
+// variables and Config limits still take precedence over its defaults.
+//
+// A validator in Types is consulted after RegisterSchemaValidator and
+// RegisterValidator registrations and before the built-in rules. It panics if
+// spec.Namespace is not a valid name.
+func RegisterNamespace(spec NamespaceSpec) {
+	if err := checkName("namespace", spec.Namespace); err != nil {
+		panic("interband: RegisterNamespace: " + err.Error())
+	}
+	spec.Channels = append([]ChannelDefault(nil), spec.Channels...)
+	spec.Types = maps.Clone(spec.Types)
+	namespacesMu.Lock()
+	defer namespacesMu.Unlock()
+	namespaces[spec.Namespace] = spec
+}
+
+func registeredNamespace(namespace string) (NamespaceSpec, bool) {
+	namespacesMu.RLock()
+	defer namespacesMu.RUnlock()
+	spec, ok := namespaces[namespace]
+	return spec, ok
+}
+
+// channelDefault resolves a channel's limits from its namespace's spec. The
+// result's limits are zero where neither the channel nor the namespace sets
+// one.
+func channelDefault(namespace, channel string) (ChannelDefault, bool) {
+	spec, ok := registeredNamespace(namespace)
+	if !ok {
+		return ChannelDefault{}, false
+	}
+	d := ChannelDefault{Namespace: namespace, Channel: channel}
+	for _, ch := range spec.Channels {
+		if ch.Channel == channel {
+			d.RetentionSeconds, d.MaxFiles = ch.RetentionSeconds, ch.MaxFiles
+			break
+		}
+	}
+	if d.RetentionSeconds <= 0 {
+		d.RetentionSeconds = spec.RetentionSeconds
+	}
+	if d.MaxFiles <= 0 {
+		d.MaxFiles = spec.MaxFiles
+	}
+	return d, true
+}
+
+// namespaceValidator returns the validator registered for typ through
+// RegisterNamespace. known is false when the namespace lists types and typ is
+// not among them.
+func namespaceValidator(namespace, typ string) (fn PayloadValidator, known bool) {
+	spec, ok := registeredNamespace(namespace)
+	if !ok || len(spec.Types) == 0 {
+		return nil, true
+	}
+	fn, known = spec.Types[typ]
+	return fn, known
+}
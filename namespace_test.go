@@ -0,0 +1,61 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegisterNamespace(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Cleanup(func() {
+		namespacesMu.Lock()
+		delete(namespaces, "teamx")
+		namespacesMu.Unlock()
+	})
+
+	RegisterNamespace(NamespaceSpec{
+		Namespace:        "teamx",
+		RetentionSeconds: 600,
+		MaxFiles:         10,
+		Channels:         []ChannelDefault{{Channel: "hot", MaxFiles: 2}},
+		Types: map[string]PayloadValidator{
+			"ping": nil,
+			"job": func(p map[string]any) error {
+				if _, ok := p["id"].(string); !ok {
+					return errors.New("id is required")
+				}
+				return nil
+			},
+		},
+		SessionID: func() string { return "teamx-session" },
+	})
+
+	if got := DefaultRetentionSeconds("teamx", "any"); got != 600 {
+		t.Fatalf("namespace retention not applied: %d", got)
+	}
+	if DefaultMaxFiles("teamx", "hot") != 2 || DefaultRetentionSeconds("teamx", "hot") != 600 {
+		t.Fatal("channel override not applied over namespace defaults")
+	}
+	if DefaultMaxFiles("interphase", "bead") != 256 {
+		t.Fatal("built-in namespace defaults lost")
+	}
+
+	if err := Validate("teamx", "ping", map[string]any{}); err != nil {
+		t.Fatalf("known type rejected: %v", err)
+	}
+	if err := Validate("teamx", "job", map[string]any{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected namespace validator to reject, got %v", err)
+	}
+	if err := Validate("teamx", "other", map[string]any{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected unknown type to be rejected, got %v", err)
+	}
+
+	p, _ := Path("teamx", "hot", "k")
+	if err := Write(p, "teamx", "ping", "", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	env, err := ReadEnvelope(p)
+	if err != nil || env.SessionID != "teamx-session" {
+		t.Fatalf("expected derived session, got %+v %v", env, err)
+	}
+}
@@ -0,0 +1,78 @@
+package interband
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Observer receives callbacks at interband's key points, for metrics. Calls
+// are synchronous, so implementations should be cheap; a panicking observer
+// is recovered and logged without affecting the operation. Embed NopObserver
+// to implement only some methods.
+type Observer interface {
+	// OnWrite follows a successful envelope write of size bytes.
+	OnWrite(namespace, typ string, bytes int)
+	// OnRead follows a successful envelope read of size bytes.
+	OnRead(namespace, typ string, bytes int)
+	// OnValidationError reports a write or read rejected with a
+	// *ValidationError.
+	OnValidationError(err error)
+	// OnPrune follows a prune that ran (was not skipped by the interval).
+	OnPrune(namespace, channel string, removed int)
+}
+
+// NopObserver ignores every callback.
+type NopObserver struct{}
+
+func (NopObserver) OnWrite(string, string, int) {}
+func (NopObserver) OnRead(string, string, int)  {}
+func (NopObserver) OnValidationError(error)     {}
+func (NopObserver) OnPrune(string, string, int) {}
+
+type observerBox struct{ o Observer }
+
+var globalObserver atomic.Pointer[observerBox]
+
+// SetObserver installs the observer used by the package-level functions and by
+// clients whose Config.Observer is nil. A nil observer restores the no-op
+// default.
+func SetObserver(o Observer) {
+	if o == nil {
+		globalObserver.Store(nil)
+		return
+	}
+	globalObserver.Store(&observerBox{o: o})
+}
+
+func (c *Client) observer() Observer {
+	if c.cfg.Observer != nil {
+		return c.cfg.Observer
+	}
+	if box := globalObserver.Load(); box != nil {
+		return box.o
+	}
+	return nil
+}
+
+// observe runs fn against the client's observer, if any, recovering panics.
+func (c *Client) observe(fn func(Observer)) {
+	o := c.observer()
+	if o == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logWarn("interband: observer panicked", "panic", r)
+		}
+	}()
+	fn(o)
+}
+
+// observeValidation reports err to the observer when it is a validation
+// failure and returns it unchanged.
+func (c *Client) observeValidation(err error) error {
+	if err != nil && errors.Is(err, ErrValidation) {
+		c.observe(func(o Observer) { o.OnValidationError(err) })
+	}
+	return err
+}
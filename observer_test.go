@@ -0,0 +1,84 @@
+package interband
+
+import (
+	"sync"
+	"testing"
+)
+
+type recordingObserver struct {
+	mu      sync.Mutex
+	writes  int
+	reads   int
+	invalid int
+	pruned  int
+}
+
+func (r *recordingObserver) OnWrite(_, _ string, bytes int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if bytes > 0 {
+		r.writes++
+	}
+}
+
+func (r *recordingObserver) OnRead(_, _ string, _ int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+}
+
+func (r *recordingObserver) OnValidationError(error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invalid++
+}
+
+func (r *recordingObserver) OnPrune(_, _ string, removed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruned += removed
+}
+
+type panickyObserver struct{ NopObserver }
+
+func (panickyObserver) OnWrite(string, string, int) { panic("boom") }
+
+func TestObserver(t *testing.T) {
+	rec := &recordingObserver{}
+	c := NewClient(Config{Root: t.TempDir(), Observer: rec, MaxFiles: map[ChannelRef]int{{Namespace: "custom", Channel: "events"}: 1}})
+
+	for _, key := range []string{"a", "b"} {
+		p, _ := c.Path("custom", "events", key)
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		if _, err := c.ReadEnvelope(p); err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if _, err := c.ReadEnvelopeStrict(p); err != nil {
+			t.Fatalf("strict read failed: %v", err)
+		}
+		var payload map[string]any
+		if err := c.ReadPayloadInto(p, &payload); err != nil {
+			t.Fatalf("read into failed: %v", err)
+		}
+	}
+	p, _ := c.Path("interphase", "bead", "x")
+	if err := c.Write(p, "interphase", "bead_phase", "s", map[string]any{}); err == nil {
+		t.Fatal("expected validation failure")
+	}
+	if _, err := c.PruneChannelStats("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if rec.writes != 2 || rec.reads != 6 || rec.invalid != 1 || rec.pruned != 1 {
+		t.Fatalf("unexpected observations: %+v", rec)
+	}
+
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	SetObserver(panickyObserver{})
+	t.Cleanup(func() { SetObserver(nil) })
+	p, _ = Path("custom", "events", "k")
+	if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("panicking observer broke the write: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package interband
+
+import "time"
+
+// WriteOption adjusts the envelope Write stamps.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	timestamp time.Time
+	version   string
+	sessionID *string
+}
+
+// WithTimestamp records t, such as when an imported event occurred, instead
+// of the write time. It is formatted at the client's timestamp precision.
+func WithTimestamp(t time.Time) WriteOption {
+	return func(o *writeOptions) { o.timestamp = t }
+}
+
+// WithVersion records v instead of the client's protocol version. The
+// envelope is still validated, so v must be a version readers accept.
+func WithVersion(v string) WriteOption {
+	return func(o *writeOptions) { o.version = v }
+}
+
+// WithSessionID records id in place of the sessionID argument.
+func WithSessionID(id string) WriteOption {
+	return func(o *writeOptions) { o.sessionID = &id }
+}
+
+func collectWriteOptions(opts []WriteOption) writeOptions {
+	var o writeOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// newEnvelopeWith is newEnvelope with opts applied.
+func (c *Client) newEnvelopeWith(targetPath, namespace, typ, sessionID string, payload map[string]any, opts []WriteOption) (Envelope, error) {
+	o := collectWriteOptions(opts)
+	if o.sessionID != nil {
+		sessionID = *o.sessionID
+	}
+	env, err := c.newEnvelope(targetPath, namespace, typ, sessionID, payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if !o.timestamp.IsZero() {
+		env.Timestamp = o.timestamp.UTC().Format(timestampLayout(c.cfg.TimestampPrecision))
+	}
+	if o.version != "" {
+		env.Version = o.version
+	}
+	return env, nil
+}
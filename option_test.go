@@ -0,0 +1,39 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWriteOptions(t *testing.T) {
+	c, _ := newMemClient(t)
+	p, _ := c.Path("custom", "imports", "evt")
+	occurred := time.Date(2023, 5, 6, 7, 8, 9, 0, time.FixedZone("x", 3600))
+	err := c.Write(p, "custom", "imported", "ignored", map[string]any{},
+		WithTimestamp(occurred), WithVersion("1.2.0"), WithSessionID("importer"))
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	env, err := c.ReadEnvelope(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if at, _ := env.Time(); !at.Equal(occurred) {
+		t.Fatalf("timestamp = %s, want %s", env.Timestamp, occurred)
+	}
+	if env.Version != "1.2.0" || env.SessionID != "importer" {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+
+	if err := c.Write(p, "custom", "imported", "s", map[string]any{}, WithVersion("2.0.0")); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected an unreadable version to be rejected, got %v", err)
+	}
+	if err := c.WriteKey("custom", "imports", "k", "imported", "s", map[string]any{}, WithSessionID("")); err != nil {
+		t.Fatalf("write key failed: %v", err)
+	}
+	kp, _ := c.Path("custom", "imports", "k")
+	if env, err := c.ReadEnvelope(kp); err != nil || env.SessionID != "" {
+		t.Fatalf("WithSessionID(\"\") should clear the session, got %+v, %v", env, err)
+	}
+}
@@ -0,0 +1,205 @@
+package interband
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+)
+
+// BeadPhase is the payload of an interphase/bead_phase message.
+type BeadPhase struct {
+	ID     string
+	Phase  string
+	Reason string
+	TS     float64
+}
+
+// ClavainDispatch is the payload of a clavain/dispatch message.
+type ClavainDispatch struct {
+	Name     string
+	Workdir  string
+	Activity string
+	Started  float64
+	Turns    float64
+	Commands float64
+	Messages float64
+}
+
+// InterlockSignal is the payload of an interlock/coordination_signal message.
+type InterlockSignal struct {
+	Layer    string
+	Icon     string
+	Text     string
+	Priority float64
+	TS       string
+}
+
+// AsBeadPhase decodes an interphase/bead_phase envelope.
+func (e Envelope) AsBeadPhase() (BeadPhase, error) {
+	if err := e.expectPayload("interphase", "bead_phase"); err != nil {
+		return BeadPhase{}, err
+	}
+	p := e.Payload
+	out := BeadPhase{ID: stringField(p, "id"), Phase: stringField(p, "phase"), Reason: stringField(p, "reason")}
+	var ok bool
+	if out.TS, ok = toFloat64(p["ts"]); !ok {
+		return BeadPhase{}, invalidPayload("interphase", "bead_phase", "ts", "ts must be numeric")
+	}
+	return out, nil
+}
+
+// AsClavainDispatch decodes a clavain/dispatch envelope.
+func (e Envelope) AsClavainDispatch() (ClavainDispatch, error) {
+	if err := e.expectPayload("clavain", "dispatch"); err != nil {
+		return ClavainDispatch{}, err
+	}
+	p := e.Payload
+	out := ClavainDispatch{Name: stringField(p, "name"), Workdir: stringField(p, "workdir"), Activity: stringField(p, "activity")}
+	for key, dst := range map[string]*float64{
+		"started":  &out.Started,
+		"turns":    &out.Turns,
+		"commands": &out.Commands,
+		"messages": &out.Messages,
+	} {
+		v, ok := toFloat64(p[key])
+		if !ok || v < 0 {
+			return ClavainDispatch{}, invalidPayload("clavain", "dispatch", key, "%s must be a non-negative number", key)
+		}
+		*dst = v
+	}
+	return out, nil
+}
+
+// AsInterlockSignal decodes an interlock/coordination_signal envelope.
+func (e Envelope) AsInterlockSignal() (InterlockSignal, error) {
+	if err := e.expectPayload("interlock", "coordination_signal"); err != nil {
+		return InterlockSignal{}, err
+	}
+	p := e.Payload
+	out := InterlockSignal{Layer: stringField(p, "layer"), Icon: stringField(p, "icon"), Text: stringField(p, "text"), TS: stringField(p, "ts")}
+	v, ok := toFloat64(p["priority"])
+	if !ok || v < 0 {
+		return InterlockSignal{}, invalidPayload("interlock", "coordination_signal", "priority", "priority must be a non-negative number")
+	}
+	out.Priority = v
+	return out, nil
+}
+
+// expectPayload checks the envelope carries namespace/type and that its
+// payload passes the read-side validation for that pair.
+func (e Envelope) expectPayload(namespace, typ string) error {
+	if e.Namespace != namespace || e.Type != typ {
+		return invalidEnvelope("type", "envelope is %s/%s, not %s/%s", e.Namespace, e.Type, namespace, typ)
+	}
+	_, err := validatePayload(namespace, typ, e.Payload, !strictReads())
+	return err
+}
+
+// PayloadInt returns payload[key] as an integer. Any number the validators
+// accept is converted, whether it was decoded from JSON (float64 or
+// json.Number) or built in Go; ok is false if the field is missing, not a
+// number, fractional, or out of int64 range.
+func PayloadInt(payload map[string]any, key string) (int64, bool) {
+	v, present := payload[key]
+	if !present {
+		return 0, false
+	}
+	if n, isNum := v.(json.Number); isNum {
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+	}
+	f, ok := toFloat64(v)
+	if !ok || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// PayloadFloat returns payload[key] as a float64, accepting every numeric
+// representation PayloadInt does. ok is false if the field is missing or not
+// a number.
+func PayloadFloat(payload map[string]any, key string) (float64, bool) {
+	v, present := payload[key]
+	if !present {
+		return 0, false
+	}
+	return toFloat64(v)
+}
+
+// PayloadString returns payload[key] if it is a string.
+func PayloadString(payload map[string]any, key string) (string, bool) {
+	s, ok := payload[key].(string)
+	return s, ok
+}
+
+// PayloadBool returns payload[key] if it is a bool.
+func PayloadBool(payload map[string]any, key string) (bool, bool) {
+	b, ok := payload[key].(bool)
+	return b, ok
+}
+
+func stringField(payload map[string]any, key string) string {
+	s, _ := payload[key].(string)
+	return s
+}
+
+// NormalizePayload returns a copy of payload with every number, at any depth,
+// converted to float64 — the form json.Unmarshal produces — so a payload built
+// in Go with ints looks exactly like its read-back form. Write applies it
+// before validating. Integers beyond 2^53 lose precision.
+//
+// Numeric timestamp fields such as bead_phase's ts are Unix epoch seconds and
+// may be fractional.
+func NormalizePayload(payload map[string]any) map[string]any {
+	if payload == nil {
+		return nil
+	}
+	out := make(map[string]any, len(payload))
+	for k, v := range payload {
+		out[k] = normalizeValue(v)
+	}
+	return out
+}
+
+func normalizeValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		return NormalizePayload(x)
+	case []any:
+		out := make([]any, len(x))
+		for idx, item := range x {
+			out[idx] = normalizeValue(item)
+		}
+		return out
+	case string, bool, nil:
+		return v
+	}
+	if n, ok := toFloat64(v); ok {
+		return n
+	}
+	return v
+}
+
+// toFloat64 converts any Go or JSON number, including named numeric types,
+// to float64.
+func toFloat64(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,127 @@
+package interband
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTypedAccessors(t *testing.T) {
+	bead := Envelope{Namespace: "interphase", Type: "bead_phase", Payload: map[string]any{
+		"id": "iv-1", "phase": "executing", "reason": "go", "ts": 12.0,
+	}}
+	bp, err := bead.AsBeadPhase()
+	if err != nil {
+		t.Fatalf("bead phase decode failed: %v", err)
+	}
+	if bp.ID != "iv-1" || bp.Phase != "executing" || bp.Reason != "go" || bp.TS != 12 {
+		t.Fatalf("unexpected bead phase: %+v", bp)
+	}
+
+	dispatch := Envelope{Namespace: "clavain", Type: "dispatch", Payload: map[string]any{
+		"name": "n", "workdir": "/w", "activity": "a", "started": 1, "turns": 2, "commands": 3, "messages": 4.0,
+	}}
+	cd, err := dispatch.AsClavainDispatch()
+	if err != nil {
+		t.Fatalf("dispatch decode failed: %v", err)
+	}
+	if cd.Turns != 2 || cd.Messages != 4 || cd.Workdir != "/w" {
+		t.Fatalf("unexpected dispatch: %+v", cd)
+	}
+
+	signal := Envelope{Namespace: "interlock", Type: "coordination_signal", Payload: map[string]any{
+		"layer": "l", "icon": "i", "text": "t", "priority": 2, "ts": "now",
+	}}
+	sig, err := signal.AsInterlockSignal()
+	if err != nil {
+		t.Fatalf("signal decode failed: %v", err)
+	}
+	if sig.Priority != 2 || sig.TS != "now" {
+		t.Fatalf("unexpected signal: %+v", sig)
+	}
+
+	if _, err := dispatch.AsBeadPhase(); err == nil {
+		t.Fatal("expected mismatched namespace/type to fail")
+	}
+	bad := Envelope{Namespace: "clavain", Type: "dispatch", Payload: map[string]any{"name": "n"}}
+	if _, err := bad.AsClavainDispatch(); err == nil {
+		t.Fatal("expected invalid payload to fail")
+	}
+}
+
+func TestNormalizePayloadRoundTrip(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	type seconds int64
+	payload := map[string]any{
+		"id":     "iv-1",
+		"phase":  "planned",
+		"reason": "r",
+		"ts":     seconds(1700000000),
+		"nested": map[string]any{"n": uint8(3), "list": []any{1, int32(2), "x"}},
+	}
+	if err := ValidatePayload("interphase", "bead_phase", payload); err != nil {
+		t.Fatalf("named int ts rejected: %v", err)
+	}
+	norm := NormalizePayload(payload)
+	if norm["ts"] != float64(1700000000) || norm["nested"].(map[string]any)["list"].([]any)[1] != float64(2) {
+		t.Fatalf("unexpected normalized payload: %v", norm)
+	}
+	if _, ok := payload["ts"].(seconds); !ok {
+		t.Fatal("NormalizePayload must not modify its input")
+	}
+
+	p, _ := Path("interphase", "bead", "k")
+	if err := Write(p, "interphase", "bead_phase", "s", payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	back, err := ReadPayload(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if !reflect.DeepEqual(back, norm) {
+		t.Fatalf("read-back %v differs from normalized %v", back, norm)
+	}
+	if err := ValidatePayload("interphase", "bead_phase", back); err != nil {
+		t.Fatalf("read-back float form rejected: %v", err)
+	}
+}
+
+func TestPayloadAccessors(t *testing.T) {
+	type count uint16
+	payload := map[string]any{
+		"f": 3.0, "i": 7, "u": count(9), "n": json.Number("12345678901234567"),
+		"frac": 1.5, "huge": 1e300, "s": "x", "b": true,
+	}
+	for key, want := range map[string]int64{"f": 3, "i": 7, "u": 9, "n": 12345678901234567} {
+		if got, ok := PayloadInt(payload, key); !ok || got != want {
+			t.Fatalf("PayloadInt(%s) = %d, %v; want %d", key, got, ok, want)
+		}
+	}
+	for _, key := range []string{"frac", "huge", "s", "missing"} {
+		if _, ok := PayloadInt(payload, key); ok {
+			t.Fatalf("PayloadInt(%s) should fail", key)
+		}
+	}
+	if got, ok := PayloadFloat(payload, "frac"); !ok || got != 1.5 {
+		t.Fatalf("PayloadFloat(frac) = %v, %v", got, ok)
+	}
+	if got, ok := PayloadFloat(payload, "u"); !ok || got != 9 {
+		t.Fatalf("PayloadFloat(u) = %v, %v", got, ok)
+	}
+	if _, ok := PayloadFloat(payload, "s"); ok {
+		t.Fatal("PayloadFloat(s) should fail")
+	}
+	if got, ok := PayloadString(payload, "s"); !ok || got != "x" {
+		t.Fatalf("PayloadString(s) = %q, %v", got, ok)
+	}
+	if _, ok := PayloadString(payload, "b"); ok {
+		t.Fatal("PayloadString(b) should fail")
+	}
+	if got, ok := PayloadBool(payload, "b"); !ok || !got {
+		t.Fatalf("PayloadBool(b) = %v, %v", got, ok)
+	}
+	if _, ok := PayloadBool(nil, "b"); ok {
+		t.Fatal("PayloadBool on nil payload should fail")
+	}
+}
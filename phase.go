@@ -0,0 +1,74 @@
+package interband
+
+// beadPhases lists the interphase/bead_phase phases in lifecycle order.
+var beadPhases = []string{
+	"brainstorm",
+	"brainstorm-reviewed",
+	"strategized",
+	"planned",
+	"plan-reviewed",
+	"executing",
+	"shipping",
+	"done",
+}
+
+var allowedPhases = func() map[string]struct{} {
+	out := make(map[string]struct{}, len(beadPhases))
+	for _, phase := range beadPhases {
+		out[phase] = struct{}{}
+	}
+	return out
+}()
+
+// BeadPhases returns the bead_phase phases in lifecycle order.
+func BeadPhases() []string {
+	return append([]string(nil), beadPhases...)
+}
+
+func phaseIndex(phase string) int {
+	for idx, p := range beadPhases {
+		if p == phase {
+			return idx
+		}
+	}
+	return -1
+}
+
+// ValidPhaseTransition reports whether a bead may move from one phase to
+// another: forward in BeadPhases order, skipping phases if need be, or to the
+// phase it is already in. An empty from is a new bead, which may start in any
+// phase. Unknown phases are never valid.
+func ValidPhaseTransition(from, to string) bool {
+	next := phaseIndex(to)
+	if next < 0 {
+		return false
+	}
+	if from == "" {
+		return true
+	}
+	prev := phaseIndex(from)
+	return prev >= 0 && prev <= next
+}
+
+// checkPhaseTransition enforces ValidPhaseTransition between the bead_phase
+// message at targetPath and env, when the client checks transitions. Nothing
+// is checked when there is no readable message for the same bead there, or
+// when its phase is one this reader does not know.
+func (c *Client) checkPhaseTransition(targetPath string, env Envelope) error {
+	if !c.cfg.CheckTransitions || env.Namespace != "interphase" || env.Type != "bead_phase" {
+		return nil
+	}
+	prev, err := c.ReadEnvelope(targetPath)
+	if err != nil || prev.Namespace != env.Namespace || prev.Type != env.Type {
+		return nil
+	}
+	id := stringField(env.Payload, "id")
+	if stringField(prev.Payload, "id") != id {
+		return nil
+	}
+	from, to := stringField(prev.Payload, "phase"), stringField(env.Payload, "phase")
+	if phaseIndex(from) < 0 || ValidPhaseTransition(from, to) {
+		return nil
+	}
+	return invalidPayload(env.Namespace, env.Type, "phase", "bead %s cannot move from phase %q back to %q", id, from, to)
+}
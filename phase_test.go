@@ -0,0 +1,82 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidPhaseTransition(t *testing.T) {
+	phases := BeadPhases()
+	if len(phases) != len(allowedPhases) || phases[0] != "brainstorm" || phases[len(phases)-1] != "done" {
+		t.Fatalf("unexpected phase order: %v", phases)
+	}
+	phases[0] = "mutated"
+	if BeadPhases()[0] != "brainstorm" {
+		t.Fatal("BeadPhases should return a copy")
+	}
+
+	for _, tc := range []struct {
+		from, to string
+		want     bool
+	}{
+		{"planned", "executing", true},
+		{"planned", "planned", true},
+		{"brainstorm", "done", true},
+		{"", "executing", true},
+		{"done", "brainstorm", false},
+		{"executing", "planned", false},
+		{"planned", "someday", false},
+		{"someday", "planned", false},
+	} {
+		if got := ValidPhaseTransition(tc.from, tc.to); got != tc.want {
+			t.Fatalf("ValidPhaseTransition(%q, %q) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestCheckTransitionsOnWrite(t *testing.T) {
+	c, _ := newMemClient(t)
+	p, _ := c.Path("interphase", "bead", "session-1")
+	bead := func(id, phase string) map[string]any {
+		return map[string]any{"id": id, "phase": phase, "reason": "r", "ts": 1}
+	}
+	if err := c.Write(p, "interphase", "bead_phase", "session-1", bead("iv-1", "done")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	// Transition checks are opt-in.
+	if err := c.Write(p, "interphase", "bead_phase", "session-1", bead("iv-1", "executing")); err != nil {
+		t.Fatalf("unchecked backwards write failed: %v", err)
+	}
+
+	c.cfg.CheckTransitions = true
+	if err := c.Write(p, "interphase", "bead_phase", "session-1", bead("iv-1", "shipping")); err != nil {
+		t.Fatalf("forward write failed: %v", err)
+	}
+	err := c.Write(p, "interphase", "bead_phase", "session-1", bead("iv-1", "planned"))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Field != "phase" {
+		t.Fatalf("expected a phase validation error, got %v", err)
+	}
+	// A different bead at the same key starts fresh.
+	if err := c.Write(p, "interphase", "bead_phase", "session-1", bead("iv-2", "brainstorm")); err != nil {
+		t.Fatalf("new bead write failed: %v", err)
+	}
+}
+
+func TestCheckTransitionsOnCompressedWrite(t *testing.T) {
+	c, _ := newMemClient(t)
+	c.cfg.CheckTransitions = true
+	p, _ := c.Path("interphase", "bead", "session-1")
+	p += CompressedExt
+	bead := func(phase string) map[string]any {
+		return map[string]any{"id": "iv-1", "phase": phase, "reason": "r", "ts": 1}
+	}
+	if err := c.WriteCompressed(p, "interphase", "bead_phase", "session-1", bead("done")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	err := c.WriteCompressed(p, "interphase", "bead_phase", "session-1", bead("planned"))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Field != "phase" {
+		t.Fatalf("expected a phase validation error, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package interband
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PruneStats reports the outcome of pruning one channel.
+type PruneStats struct {
+	// Expired counts files removed for exceeding the retention window.
+	Expired int
+	// Overflow counts files removed to enforce the max-files cap. A channel
+	// that keeps reporting overflow has producers outrunning its consumers.
+	Overflow int
+	// Remaining counts envelope files left in the channel.
+	Remaining int
+	// Skipped is set when the prune interval had not elapsed.
+	Skipped bool
+	// Partial is set when MaxPruneRemovals stopped the pass before it was
+	// done. The prune stamp is left alone, so the next prune continues.
+	Partial bool
+}
+
+// Removed is the total number of files removed.
+func (s PruneStats) Removed() int {
+	return s.Expired + s.Overflow
+}
+
+// PruneAll prunes every channel found two levels below Root, continuing past
+// failures, and returns the stats for each channel visited.
+func PruneAll() (map[ChannelRef]PruneStats, error) {
+	return envClient().PruneAll()
+}
+
+func (c *Client) PruneAll() (map[ChannelRef]PruneStats, error) {
+	if err := c.writable(); err != nil {
+		return nil, err
+	}
+	summary := make(map[ChannelRef]PruneStats)
+	namespaces, err := c.listSubdirs(c.cfg.Root)
+	if err != nil {
+		return summary, err
+	}
+
+	var errs []error
+	for _, ns := range namespaces {
+		channels, err := c.listSubdirs(filepath.Join(c.cfg.Root, ns))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, ch := range channels {
+			stats, err := c.PruneChannelStats(ns, ch)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", ns, ch, err))
+			}
+			summary[ChannelRef{Namespace: ns, Channel: ch}] = stats
+		}
+	}
+	return summary, errors.Join(errs...)
+}
+
+// listSubdirs returns the non-hidden subdirectories of dir, or none when dir
+// does not exist.
+func (c *Client) listSubdirs(dir string) ([]string, error) {
+	entries, err := c.backend().ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			out = append(out, entry.Name())
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,398 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPruneAll(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.PruneInterval = 0
+	cfg.RetentionSeconds = map[ChannelRef]int{
+		{"custom", "a"}: 1,
+		{"custom", "b"}: 1,
+	}
+	c := NewClient(cfg)
+
+	old := time.Now().Add(-time.Minute)
+	for _, ch := range []string{"a", "b"} {
+		for _, key := range []string{"old", "new"} {
+			p, err := c.Path("custom", ch, key)
+			if err != nil {
+				t.Fatalf("path error: %v", err)
+			}
+			if err := c.Write(p, "custom", "anything", "s", map[string]any{"k": key}); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if key == "old" {
+				if err := os.Chtimes(p, old, old); err != nil {
+					t.Fatalf("chtimes failed: %v", err)
+				}
+			}
+		}
+	}
+
+	summary, err := c.PruneAll()
+	if err != nil {
+		t.Fatalf("prune all failed: %v", err)
+	}
+	if len(summary) != 2 || summary[ChannelRef{"custom", "a"}].Removed() != 1 || summary[ChannelRef{"custom", "b"}].Removed() != 1 {
+		t.Fatalf("unexpected summary: %#v", summary)
+	}
+
+	empty := NewClient(Config{Root: t.TempDir() + "/missing"})
+	summary, err = empty.PruneAll()
+	if err != nil || len(summary) != 0 {
+		t.Fatalf("expected empty summary for missing root, summary=%v err=%v", summary, err)
+	}
+}
+
+func TestPruneChannelStats(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.PruneInterval = time.Hour
+	cfg.RetentionSeconds = map[ChannelRef]int{{"custom", "events"}: 30}
+	cfg.MaxFiles = map[ChannelRef]int{{"custom", "events"}: 2}
+	c := NewClient(cfg)
+
+	for idx, key := range []string{"expired", "a", "b", "c"} {
+		p, err := c.Path("custom", "events", key)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{"k": key}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		mt := time.Now().Add(time.Duration(idx-4) * time.Second)
+		if key == "expired" {
+			mt = time.Now().Add(-time.Minute)
+		}
+		if err := os.Chtimes(p, mt, mt); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	stats, err := c.PruneChannelStats("custom", "events")
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if stats != (PruneStats{Expired: 1, Overflow: 1, Remaining: 2}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	stats, err = c.PruneChannelStats("custom", "events")
+	if err != nil || !stats.Skipped {
+		t.Fatalf("expected interval to skip second prune, stats=%+v err=%v", stats, err)
+	}
+}
+
+func TestPruneMaxFilesTieBreakIsDeterministic(t *testing.T) {
+	for run := 0; run < 5; run++ {
+		c, mem := newMemClient(t)
+		ref := ChannelRef{Namespace: "custom", Channel: "events"}
+		c.cfg.MaxFiles = map[ChannelRef]int{ref: 3}
+		c.cfg.RetentionSeconds = map[ChannelRef]int{ref: 3600}
+
+		same := time.Now().Add(-time.Minute).Truncate(time.Second)
+		for _, key := range []string{"c", "a", "e", "b", "d"} {
+			p, _ := c.Path("custom", "events", key)
+			if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if err := mem.Chtimes(p, same); err != nil {
+				t.Fatalf("chtimes failed: %v", err)
+			}
+		}
+		newer, _ := c.Path("custom", "events", "0-newest")
+		if err := c.Write(newer, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+
+		if _, err := c.PruneChannelStats("custom", "events"); err != nil {
+			t.Fatalf("prune failed: %v", err)
+		}
+		for key, want := range map[string]bool{"0-newest": true, "e": true, "d": true, "c": false, "b": false, "a": false} {
+			if ok, _ := c.Exists("custom", "events", key); ok != want {
+				t.Fatalf("run %d: key %s exists=%v, want %v", run, key, ok, want)
+			}
+		}
+	}
+}
+
+func TestPruneByTimestampIgnoresModTime(t *testing.T) {
+	c, mem := newMemClient(t)
+	ref := ChannelRef{Namespace: "custom", Channel: "events"}
+	c.cfg.RetentionSeconds = map[ChannelRef]int{ref: 3600}
+	c.cfg.PruneByTimestamp = true
+
+	stale, _ := c.Path("custom", "events", "stale")
+	fresh, _ := c.Path("custom", "events", "fresh")
+	for _, p := range []string{stale, fresh} {
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	env, err := c.ReadEnvelope(stale)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	env.Timestamp = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	if err := c.WriteEnvelope(stale, env); err != nil {
+		t.Fatalf("rewrite failed: %v", err)
+	}
+	// A restored backup: the fresh envelope's file looks ancient.
+	if err := mem.Chtimes(fresh, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	stats, err := c.PruneChannelStats("custom", "events")
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if stats.Expired != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if ok, _ := c.Exists("custom", "events", "fresh"); !ok {
+		t.Fatal("fresh envelope should survive despite old mod-time")
+	}
+	if ok, _ := c.Exists("custom", "events", "stale"); ok {
+		t.Fatal("stale envelope should be pruned despite new mod-time")
+	}
+}
+
+func TestPruneChannelDryRunRemovesNothing(t *testing.T) {
+	c, mem := newMemClient(t)
+	ref := ChannelRef{Namespace: "custom", Channel: "events"}
+	c.cfg.RetentionSeconds = map[ChannelRef]int{ref: 3600}
+	c.cfg.MaxFiles = map[ChannelRef]int{ref: 1}
+
+	now := time.Now()
+	times := map[string]time.Time{
+		"expired": now.Add(-2 * time.Hour),
+		"older":   now.Add(-2 * time.Minute),
+		"newest":  now.Add(-time.Minute),
+	}
+	for key, at := range times {
+		p, _ := c.Path("custom", "events", key)
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		if err := mem.Chtimes(p, at); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	got, err := c.PruneChannelDryRun("custom", "events")
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	expiredPath, _ := c.Path("custom", "events", "expired")
+	olderPath, _ := c.Path("custom", "events", "older")
+	if len(got) != 2 || got[0] != expiredPath || got[1] != olderPath {
+		t.Fatalf("unexpected dry run: %v", got)
+	}
+	dir, _ := c.ChannelDir("custom", "events")
+	if _, err := mem.Stat(filepath.Join(dir, ".interband-prune.stamp")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("dry run should not write the stamp, stat err=%v", err)
+	}
+	for key := range times {
+		if ok, _ := c.Exists("custom", "events", key); !ok {
+			t.Fatalf("dry run removed %s", key)
+		}
+	}
+
+	// A removal budget limits the dry run as it would the real pass.
+	c.cfg.MaxPruneRemovals = 1
+	got, err = c.PruneChannelDryRun("custom", "events")
+	if err != nil || len(got) != 1 || got[0] != expiredPath {
+		t.Fatalf("unexpected budgeted dry run: %v, %v", got, err)
+	}
+}
+
+func TestShouldPrune(t *testing.T) {
+	c, _ := newMemClient(t)
+	c.cfg.PruneInterval = time.Hour
+
+	if due, err := c.ShouldPrune("custom", "events"); err != nil || due {
+		t.Fatalf("missing channel should not be due: %v %v", due, err)
+	}
+	p, _ := c.Path("custom", "events", "k")
+	if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if due, err := c.ShouldPrune("custom", "events"); err != nil || !due {
+		t.Fatalf("unstamped channel should be due: %v %v", due, err)
+	}
+	if err := c.PruneChannel("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if due, err := c.ShouldPrune("custom", "events"); err != nil || due {
+		t.Fatalf("freshly pruned channel should not be due: %v %v", due, err)
+	}
+	if stats, _ := c.PruneChannelStats("custom", "events"); !stats.Skipped {
+		t.Fatalf("expected skipped prune, got %+v", stats)
+	}
+	if _, err := c.ShouldPrune("", "events"); err == nil {
+		t.Fatal("expected error for empty namespace")
+	}
+}
+
+func TestConcurrentPrunersRunOnce(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "300")
+	t.Setenv("INTERBAND_MAX_FILES", "5")
+
+	base := time.Now().Add(-time.Minute)
+	for i := 0; i < 20; i++ {
+		p, _ := Path("custom", "events", "k"+strconv.Itoa(i))
+		if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		at := base.Add(time.Duration(i) * time.Second)
+		if err := os.Chtimes(p, at, at); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	const pruners = 8
+	var wg sync.WaitGroup
+	results := make(chan PruneStats, pruners)
+	for i := 0; i < pruners; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats, err := PruneChannelStats("custom", "events")
+			if err != nil {
+				t.Errorf("prune failed: %v", err)
+			}
+			results <- stats
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	ran, removed := 0, 0
+	for stats := range results {
+		if !stats.Skipped {
+			ran++
+		}
+		removed += stats.Removed()
+	}
+	if ran != 1 || removed != 15 {
+		t.Fatalf("expected one pruner removing 15 files, got %d pruners removing %d", ran, removed)
+	}
+	envs, _, _ := ListChannel("custom", "events")
+	if len(envs) != 5 {
+		t.Fatalf("expected 5 files left, got %d", len(envs))
+	}
+}
+
+func TestPruneGraceProtectsFreshFiles(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+	t.Setenv("INTERBAND_PRUNE_BY_TIMESTAMP", "1")
+	t.Setenv("INTERBAND_RETENTION_SECS", "3600")
+
+	history := time.Now().Add(-48 * time.Hour)
+	// Replayed history: an ancient envelope timestamp, just written.
+	replayed := writeAt(t, "custom", "events", "replayed", history, map[string]any{})
+	stale := writeAt(t, "custom", "events", "stale", history, map[string]any{})
+	if err := os.Chtimes(stale, history, history); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	t.Setenv("INTERBAND_PRUNE_GRACE_SECS", "60")
+	dry, err := PruneChannelDryRun("custom", "events")
+	if err != nil || len(dry) != 1 || dry[0] != stale {
+		t.Fatalf("dry run should only list the stale file: %v %v", dry, err)
+	}
+	stats, err := PruneChannelStats("custom", "events")
+	if err != nil || stats.Expired != 1 || stats.Remaining != 1 {
+		t.Fatalf("unexpected stats: %+v %v", stats, err)
+	}
+	if _, err := os.Stat(replayed); err != nil {
+		t.Fatalf("fresh file was pruned: %v", err)
+	}
+
+	t.Setenv("INTERBAND_PRUNE_GRACE_SECS", "0")
+	if stats, _ := PruneChannelStats("custom", "events"); stats.Expired != 1 {
+		t.Fatalf("without grace the replayed file should expire: %+v", stats)
+	}
+}
+
+func TestPruneGraceCountsTowardMaxFiles(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+	t.Setenv("INTERBAND_PRUNE_GRACE_SECS", "60")
+	t.Setenv("INTERBAND_MAX_FILES", "2")
+
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		p, _ := Path("custom", "events", "old"+strconv.Itoa(i))
+		if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		at := old.Add(time.Duration(i) * time.Second)
+		if err := os.Chtimes(p, at, at); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+	p, _ := Path("custom", "events", "fresh")
+	if err := Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	stats, err := PruneChannelStats("custom", "events")
+	if err != nil || stats.Overflow != 2 || stats.Remaining != 2 {
+		t.Fatalf("unexpected stats: %+v %v", stats, err)
+	}
+	if ok, _ := Exists("custom", "events", "fresh"); !ok {
+		t.Fatal("fresh file was pruned")
+	}
+	if ok, _ := Exists("custom", "events", "old2"); !ok {
+		t.Fatal("newest old file should be kept")
+	}
+}
+
+func TestPruneRemovalBudget(t *testing.T) {
+	c, mem := newMemClient(t)
+	c.cfg.MaxPruneRemovals = 4
+	c.cfg.PruneInterval = time.Hour
+	c.cfg.RetentionSeconds = map[ChannelRef]int{{"custom", "backlog"}: 60}
+	c.cfg.MaxFiles = map[ChannelRef]int{{"custom", "backlog"}: 3}
+
+	old := time.Now().Add(-time.Hour)
+	for idx := 0; idx < 10; idx++ {
+		p, _ := c.Path("custom", "backlog", "k"+strconv.Itoa(idx))
+		if err := c.Write(p, "custom", "anything", "s", map[string]any{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		// Six expired files and four fresh ones, one over the cap.
+		if idx < 6 {
+			if err := mem.Chtimes(p, old); err != nil {
+				t.Fatalf("chtimes failed: %v", err)
+			}
+		}
+	}
+
+	want := []PruneStats{
+		{Expired: 4, Remaining: 6, Partial: true},
+		{Expired: 2, Overflow: 1, Remaining: 3},
+		{Remaining: 0, Skipped: true},
+	}
+	for idx, w := range want {
+		stats, err := c.PruneChannelStats("custom", "backlog")
+		if err != nil {
+			t.Fatalf("prune %d failed: %v", idx, err)
+		}
+		if stats != w {
+			t.Fatalf("prune %d: got %+v, want %+v", idx, stats, w)
+		}
+	}
+}
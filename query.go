@@ -0,0 +1,87 @@
+package interband
+
+import "time"
+
+// QueryFilter narrows Query results. Zero-valued fields match everything.
+type QueryFilter struct {
+	Type      string
+	SessionID string
+	// Since and Until bound the envelope timestamp to [Since, Until).
+	Since time.Time
+	Until time.Time
+	// Limit caps the number of results; zero or less returns all matches.
+	Limit int
+}
+
+// querySinceSlack is how far an envelope's timestamp may run ahead of its
+// file's mtime before Query's mtime shortcut can miss it.
+const querySinceSlack = 24 * time.Hour
+
+// Query returns the envelopes in a channel matching filter, newest first: the
+// reverse of ListChannel order. When the client uses the system clock, files
+// last modified more than a day before Since are skipped without being read;
+// the day allows for clock skew between hosts and for WithTimestamp stamps
+// somewhat ahead of the write. An envelope stamped further ahead than that can
+// be missed by a Since filter. Bundles are expanded and unreadable files
+// skipped as in ListChannel.
+func Query(namespace, channel string, filter QueryFilter) ([]Envelope, error) {
+	return envClient().Query(namespace, channel, filter)
+}
+
+func (c *Client) Query(namespace, channel string, filter QueryFilter) ([]Envelope, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// A fake clock stamps envelopes at times unrelated to file mtimes.
+	var skipBefore time.Time
+	if !filter.Since.IsZero() && c.cfg.Clock == nil {
+		skipBefore = filter.Since.Add(-querySinceSlack)
+	}
+	var items []keyedEnvelope
+	for _, entry := range entries {
+		if entry.modTime.Before(skipBefore) {
+			continue
+		}
+		envs, err := c.readChannelFile(entry.path)
+		if err != nil {
+			c.skip(entry.path, err)
+			continue
+		}
+		for idx, env := range envs {
+			if c.expired(env) {
+				continue
+			}
+			if filter.Type != "" && env.Type != filter.Type {
+				continue
+			}
+			if filter.SessionID != "" && env.SessionID != filter.SessionID {
+				continue
+			}
+			at := parseTimestamp(env.Timestamp)
+			if !filter.Since.IsZero() && (at.IsZero() || at.Before(filter.Since)) {
+				continue
+			}
+			if !filter.Until.IsZero() && (at.IsZero() || !at.Before(filter.Until)) {
+				continue
+			}
+			items = append(items, keyedEnvelope{key: entry.key, seq: idx, at: at, env: env})
+		}
+	}
+	sortKeyed(items)
+
+	n := len(items)
+	if filter.Limit > 0 && filter.Limit < n {
+		n = filter.Limit
+	}
+	out := make([]Envelope, n)
+	for idx := range out {
+		out[idx] = items[len(items)-1-idx].env
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package interband
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQueryFiltersAndOrders(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	writeAt(t, "custom", "events", "old", now.Add(-2*time.Hour), map[string]any{"n": 1})
+	writeAt(t, "custom", "events", "mid", now.Add(-30*time.Minute), map[string]any{"n": 2})
+	writeAt(t, "custom", "events", "new", now.Add(-time.Minute), map[string]any{"n": 3})
+	other, err := Path("custom", "events", "other")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(other, "custom", "different", "sess-2", map[string]any{"n": 4}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	got, err := Query("custom", "events", QueryFilter{Type: "anything", SessionID: "sess", Since: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Payload["n"] != float64(3) || got[1].Payload["n"] != float64(2) {
+		t.Fatalf("unexpected results: %+v", got)
+	}
+
+	got, err = Query("custom", "events", QueryFilter{Type: "anything", Until: now.Add(-10 * time.Minute), Limit: 1})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Payload["n"] != float64(2) {
+		t.Fatalf("unexpected limited results: %+v", got)
+	}
+}
+
+func TestQuerySkipsFilesModifiedBeforeSince(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	now := time.Now().UTC()
+	p := writeAt(t, "custom", "events", "stale", now, map[string]any{"n": 1})
+	old := now.Add(-2 * querySinceSlack)
+	if err := os.Chtimes(p, old, old); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	got, err := Query("custom", "events", QueryFilter{Since: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected file to be skipped by mtime, got %+v", got)
+	}
+}
+
+func TestQueryFindsEnvelopesStampedAheadOfTheirWrite(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	ahead := time.Now().UTC().Add(2 * time.Hour)
+	p, err := Path("custom", "events", "scheduled")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"n": 1}, WithTimestamp(ahead)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	got, err := Query("custom", "events", QueryFilter{Since: ahead.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Payload["n"] != float64(1) {
+		t.Fatalf("expected the future-stamped envelope, got %+v", got)
+	}
+}
+
+func TestQueryWithFakeClockReadsEveryFile(t *testing.T) {
+	c, mem := newMemClient(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mem.Clock = NewFakeClock(start)
+	c.cfg.Clock = NewFakeClock(start.Add(30 * 24 * time.Hour))
+	p, err := c.Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := c.Write(p, "custom", "anything", "sess", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	got, err := c.Query("custom", "events", QueryFilter{Since: start.Add(29 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected the envelope despite its old mtime, got %+v", got)
+	}
+}
@@ -0,0 +1,179 @@
+package interband
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RawEnvelope is an envelope whose payload may be any JSON value, such as an
+// array or a scalar, rather than only an object. Only WriteRaw and
+// ReadRawEnvelope use it; other readers reject non-object payloads as
+// malformed.
+type RawEnvelope struct {
+	Version   string          `json:"version"`
+	Namespace string          `json:"namespace"`
+	Type      string          `json:"type"`
+	SessionID string          `json:"session_id"`
+	Timestamp string          `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+	Key       string          `json:"key,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+}
+
+// UnmarshalJSON accepts numeric timestamps as Envelope does.
+func (e *RawEnvelope) UnmarshalJSON(data []byte) error {
+	type plain RawEnvelope
+	var aux struct {
+		plain
+		Timestamp json.RawMessage `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*e = RawEnvelope(aux.plain)
+	ts, err := decodeTimestamp(aux.Timestamp)
+	e.Timestamp = ts
+	return err
+}
+
+// header returns the envelope without its payload.
+func (e RawEnvelope) header() Envelope {
+	return Envelope{
+		Version:   e.Version,
+		Namespace: e.Namespace,
+		Type:      e.Type,
+		SessionID: e.SessionID,
+		Timestamp: e.Timestamp,
+		Key:       e.Key,
+		Signature: e.Signature,
+	}
+}
+
+// WriteRaw writes a message whose payload is any JSON value, as Write does
+// for objects. An object payload is written and validated exactly as Write
+// would. Other values are only accepted for namespace/type pairs without
+// payload rules: a pair with a registered validator, a namespace Types
+// validator, or built-in rules keeps requiring an object.
+func WriteRaw(targetPath, namespace, typ, sessionID string, payload json.RawMessage, opts ...WriteOption) error {
+	return envClient().WriteRaw(targetPath, namespace, typ, sessionID, payload, opts...)
+}
+
+func (c *Client) WriteRaw(targetPath, namespace, typ, sessionID string, payload json.RawMessage, opts ...WriteOption) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	payload = bytes.TrimSpace(payload)
+	if !json.Valid(payload) {
+		return c.observeValidation(invalidEnvelope("payload", "payload is not valid JSON"))
+	}
+	if payload[0] == '{' {
+		var obj map[string]any
+		if err := json.Unmarshal(payload, &obj); err != nil {
+			return err
+		}
+		return c.Write(targetPath, namespace, typ, sessionID, obj, opts...)
+	}
+	if string(payload) == "null" {
+		return c.observeValidation(invalidEnvelope("payload", "payload must not be null"))
+	}
+	if err := rawPayloadAllowed(namespace, typ); err != nil {
+		return c.observeValidation(err)
+	}
+	// Stamp the header as Write would; the placeholder payload is replaced.
+	env, err := c.newEnvelopeWith(targetPath, namespace, typ, sessionID, map[string]any{}, opts)
+	if err != nil {
+		return err
+	}
+	if err := validateHeader(env, validateOptions{}); err != nil {
+		return c.observeValidation(err)
+	}
+	raw := RawEnvelope{
+		Version:   env.Version,
+		Namespace: env.Namespace,
+		Type:      env.Type,
+		SessionID: env.SessionID,
+		Timestamp: env.Timestamp,
+		Payload:   payload,
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(raw); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if err := checkSize(targetPath, int64(len(data)), c.cfg.MaxPayloadBytes); err != nil {
+		return err
+	}
+	if err := c.backend().WriteAtomic(context.Background(), targetPath, data); err != nil {
+		return err
+	}
+	c.observe(func(o Observer) { o.OnWrite(env.Namespace, env.Type, len(data)) })
+	return nil
+}
+
+// ReadRawEnvelope reads an envelope whose payload may be any JSON value. The
+// header is validated as ReadEnvelope validates it; an object payload is
+// validated as ReadEnvelope would, and other values must belong to a
+// namespace/type pair without payload rules.
+func ReadRawEnvelope(sourcePath string) (RawEnvelope, error) {
+	return envClient().ReadRawEnvelope(sourcePath)
+}
+
+func (c *Client) ReadRawEnvelope(sourcePath string) (RawEnvelope, error) {
+	if strings.TrimSpace(sourcePath) == "" {
+		return RawEnvelope{}, errors.New("source path is required")
+	}
+	data, err := c.readEnvelopeFile(sourcePath)
+	if err != nil {
+		return RawEnvelope{}, err
+	}
+	var raw RawEnvelope
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return RawEnvelope{}, malformed(err)
+	}
+	raw.Payload = bytes.TrimSpace(raw.Payload)
+	env := raw.header()
+	if len(raw.Payload) > 0 && raw.Payload[0] == '{' {
+		if err := json.Unmarshal(raw.Payload, &env.Payload); err != nil {
+			return RawEnvelope{}, malformed(err)
+		}
+		if err := validateEnvelope(env, c.readOptions()); err != nil {
+			return RawEnvelope{}, c.observeValidation(err)
+		}
+		if c.expired(env) {
+			return RawEnvelope{}, fmt.Errorf("%w: %s", ErrExpired, sourcePath)
+		}
+	} else {
+		if err := validateHeader(env, c.readOptions()); err != nil {
+			return RawEnvelope{}, c.observeValidation(err)
+		}
+		if len(raw.Payload) == 0 || string(raw.Payload) == "null" {
+			return RawEnvelope{}, c.observeValidation(invalidEnvelope("payload", "payload is required"))
+		}
+		if err := rawPayloadAllowed(env.Namespace, env.Type); err != nil {
+			return RawEnvelope{}, c.observeValidation(err)
+		}
+	}
+	c.observe(func(o Observer) { o.OnRead(env.Namespace, env.Type, len(data)) })
+	return raw, nil
+}
+
+// rawPayloadAllowed rejects a non-object payload for a namespace/type pair
+// that has payload rules.
+func rawPayloadAllowed(namespace, typ string) error {
+	_, registered := registeredValidator(namespace, typ)
+	nsFn, known := namespaceValidator(namespace, typ)
+	_, builtin := lookupSpec(namespace, typ)
+	if !known {
+		return invalidPayload(namespace, typ, "", "type is not registered for namespace %s", namespace)
+	}
+	if registered || nsFn != nil || builtin {
+		return invalidPayload(namespace, typ, "", "payload must be an object")
+	}
+	return nil
+}
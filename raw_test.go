@@ -0,0 +1,49 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWriteRawAndReadRawEnvelope(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	p, _ := Path("custom", "events", "list")
+	if err := WriteRaw(p, "custom", "ids", "s", json.RawMessage(` [1, "two", {"three": 3}] `)); err != nil {
+		t.Fatalf("raw write failed: %v", err)
+	}
+	raw, err := ReadRawEnvelope(p)
+	if err != nil {
+		t.Fatalf("raw read failed: %v", err)
+	}
+	if string(raw.Payload) != `[1,"two",{"three":3}]` || raw.Namespace != "custom" || raw.SessionID != "s" {
+		t.Fatalf("unexpected raw envelope: %+v (payload %s)", raw, raw.Payload)
+	}
+	if _, err := ReadEnvelope(p); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("object readers should see a malformed envelope, got %v", err)
+	}
+
+	obj, _ := Path("custom", "events", "obj")
+	if err := WriteRaw(obj, "custom", "ids", "s", json.RawMessage(`{"a":1}`)); err != nil {
+		t.Fatalf("object raw write failed: %v", err)
+	}
+	if env, err := ReadEnvelope(obj); err != nil || env.Payload["a"] != 1.0 {
+		t.Fatalf("object payload should read as usual: %+v %v", env, err)
+	}
+	if raw, err := ReadRawEnvelope(obj); err != nil || string(raw.Payload) != `{"a":1}` {
+		t.Fatalf("raw read of object failed: %+v %v", raw, err)
+	}
+
+	bead, _ := Path("interphase", "bead", "b")
+	for _, payload := range []string{`[1]`, `"x"`} {
+		if err := WriteRaw(bead, "interphase", "bead_phase", "s", json.RawMessage(payload)); !errors.Is(err, ErrValidation) {
+			t.Fatalf("%s: known types must require an object, got %v", payload, err)
+		}
+	}
+	for _, payload := range []string{``, `null`, `[1,`} {
+		if err := WriteRaw(p, "custom", "ids", "s", json.RawMessage(payload)); !errors.Is(err, ErrValidation) {
+			t.Fatalf("%q: expected ErrValidation, got %v", payload, err)
+		}
+	}
+}
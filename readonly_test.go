@@ -0,0 +1,61 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReadOnlyRejectsMutations(t *testing.T) {
+	mem := NewMemBackend()
+	cfg := DefaultConfig()
+	cfg.Root = "/mem"
+	cfg.PruneInterval = 0
+	cfg.Backend = mem
+	rw := NewClient(cfg)
+	p, _ := rw.Path("custom", "state", "k")
+	if err := rw.Write(p, "custom", "anything", "s", map[string]any{"v": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	cfg.ReadOnly = true
+	ro := NewClient(cfg)
+	payload := map[string]any{"v": 2}
+	mutations := map[string]func() error{
+		"Write":        func() error { return ro.Write(p, "custom", "anything", "s", payload) },
+		"WriteContext": func() error { return ro.WriteContext(context.Background(), p, "custom", "anything", "s", payload) },
+		"WriteKey":     func() error { return ro.WriteKey("custom", "state", "k", "anything", "s", payload) },
+		"WriteIfAbsent": func() error {
+			_, err := ro.WriteIfAbsent("custom", "state", "new", "anything", "s", payload)
+			return err
+		},
+		"WriteBatch": func() error {
+			_, err := ro.WriteBatch("custom", "state", []BatchItem{{Key: "b", Type: "anything", Payload: payload}})
+			return err
+		},
+		"Append": func() error { _, err := ro.Append("custom", "log", "anything", "s", payload); return err },
+		"Update": func() error {
+			return ro.Update("custom", "state", "k", "anything", "s", func(m map[string]any) (map[string]any, error) { return m, nil })
+		},
+		"Delete":       func() error { return ro.Delete("custom", "state", "k") },
+		"MoveKey":      func() error { return ro.MoveKey("custom", "state", "custom", "other", "k") },
+		"PruneChannel": func() error { return ro.PruneChannel("custom", "state") },
+		"PruneAll":     func() error { _, err := ro.PruneAll(); return err },
+		"Compact":      func() error { _, err := ro.CompactChannel("custom", "state"); return err },
+		"Lock":         func() error { return ro.WithChannelLock("custom", "state", func() error { return nil }) },
+		"Writer":       func() error { return ro.NewWriter("custom", "state").Write("k", "anything", "s", payload) },
+	}
+	for name, fn := range mutations {
+		if err := fn(); !errors.Is(err, ErrReadOnly) {
+			t.Fatalf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+
+	env, err := ro.ReadEnvelope(p)
+	if err != nil || env.Payload["v"] != 1.0 {
+		t.Fatalf("read-only client should still read the original: %v, %v", env.Payload, err)
+	}
+	if envs, _, err := ro.ListChannel("custom", "state"); err != nil || len(envs) != 1 {
+		t.Fatalf("list: %d envelopes, %v", len(envs), err)
+	}
+}
@@ -0,0 +1,93 @@
+package interband
+
+import (
+	"strconv"
+	"sync"
+)
+
+// PayloadValidator checks a payload for one namespace/type pair.
+type PayloadValidator func(payload map[string]any) error
+
+var (
+	validatorsMu     sync.RWMutex
+	validators       = map[string]PayloadValidator{}
+	schemaValidators = map[string]map[string]PayloadValidator{}
+)
+
+// SchemaField is the optional payload field naming the payload's schema
+// version, for types with validators registered per version.
+const SchemaField = "schema"
+
+// RegisterValidator installs fn as the validator for namespace/type. It is
+// safe to call from init and concurrently with reads and writes.
+//
+// Validation precedence is: a validator registered with
+// RegisterSchemaValidator for the payload's schema version, then a registered
+// validator, then one from the namespace's RegisterNamespace Types, then the
+// built-in rules
+// for interphase/bead_phase, clavain/dispatch, and interlock/coordination_signal,
+// then the permissive default that accepts any object. A registered validator
+// therefore replaces the built-in rules for the same pair. Registering a nil
+// fn removes the registration.
+func RegisterValidator(namespace, typ string, fn PayloadValidator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	if fn == nil {
+		delete(validators, namespace+":"+typ)
+		return
+	}
+	validators[namespace+":"+typ] = fn
+}
+
+func registeredValidator(namespace, typ string) (PayloadValidator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	fn, ok := validators[namespace+":"+typ]
+	return fn, ok
+}
+
+// RegisterSchemaValidator installs fn as the validator for payloads of
+// namespace/type whose schema field equals schema, so producers of several
+// payload versions can coexist. Once any version is registered for a pair,
+// a payload naming a version without one fails validation; payloads without
+// a schema field are still validated as before. A numeric schema field
+// matches its decimal form ("2"). Registering a nil fn removes the version.
+func RegisterSchemaValidator(namespace, typ, schema string, fn PayloadValidator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	pair := namespace + ":" + typ
+	if fn == nil {
+		delete(schemaValidators[pair], schema)
+		if len(schemaValidators[pair]) == 0 {
+			delete(schemaValidators, pair)
+		}
+		return
+	}
+	if schemaValidators[pair] == nil {
+		schemaValidators[pair] = map[string]PayloadValidator{}
+	}
+	schemaValidators[pair][schema] = fn
+}
+
+// schemaValidator returns the validator for payload's schema version. versioned
+// is false when the payload has no schema field or the pair has no versioned
+// validators, in which case the usual precedence applies.
+func schemaValidator(namespace, typ string, payload map[string]any) (fn PayloadValidator, schema string, versioned bool) {
+	raw, present := payload[SchemaField]
+	if !present {
+		return nil, "", false
+	}
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	byVersion, ok := schemaValidators[namespace+":"+typ]
+	if !ok {
+		return nil, "", false
+	}
+	schema, ok = raw.(string)
+	if !ok {
+		if n, isNum := toFloat64(raw); isNum {
+			schema = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+	}
+	return byVersion[schema], schema, true
+}
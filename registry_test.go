@@ -0,0 +1,98 @@
+package interband
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRegisterValidator(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Cleanup(func() { RegisterValidator("acme", "ping", nil) })
+
+	RegisterValidator("acme", "ping", func(payload map[string]any) error {
+		if !isNonEmptyString(payload["host"]) {
+			return errors.New("acme/ping: host must be a non-empty string")
+		}
+		return nil
+	})
+
+	validated, err := ValidatePayloadResult("acme", "ping", map[string]any{})
+	if err == nil || !validated {
+		t.Fatalf("expected registered validator to reject, validated=%v err=%v", validated, err)
+	}
+
+	p, err := Path("acme", "pings", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = ValidatePayload("acme", "ping", map[string]any{"host": "h"})
+		}()
+	}
+	if err := Write(p, "acme", "ping", "sess", map[string]any{"host": "h"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	wg.Wait()
+
+	RegisterValidator("acme", "ping", nil)
+	if validated, _ := ValidatePayloadResult("acme", "ping", map[string]any{}); validated {
+		t.Fatal("expected unregistered type to fall back to the permissive default")
+	}
+}
+
+func TestRegisteredValidatorOverridesBuiltin(t *testing.T) {
+	t.Cleanup(func() { RegisterValidator("clavain", "dispatch", nil) })
+	RegisterValidator("clavain", "dispatch", func(map[string]any) error { return nil })
+
+	if err := ValidatePayload("clavain", "dispatch", map[string]any{}); err != nil {
+		t.Fatalf("expected registered validator to replace built-in rules: %v", err)
+	}
+}
+
+func TestRegisterSchemaValidator(t *testing.T) {
+	t.Cleanup(func() {
+		RegisterSchemaValidator("clavain", "dispatch", "1", nil)
+		RegisterSchemaValidator("clavain", "dispatch", "2", nil)
+	})
+	v1 := map[string]any{"name": "n", "workdir": "/w", "activity": "a", "started": 1.0, "turns": 1.0, "commands": 1.0, "messages": 1.0}
+
+	RegisterSchemaValidator("clavain", "dispatch", "2", func(payload map[string]any) error {
+		if !isNonEmptyString(payload["agent"]) {
+			return invalidPayload("clavain", "dispatch", "agent", "agent must be a non-empty string")
+		}
+		return nil
+	})
+
+	// Without a schema field the built-in rules still apply.
+	if err := ValidatePayload("clavain", "dispatch", v1); err != nil {
+		t.Fatalf("unversioned payload should validate as before: %v", err)
+	}
+	if err := ValidatePayload("clavain", "dispatch", map[string]any{"schema": 2.0, "agent": "a"}); err != nil {
+		t.Fatalf("v2 payload rejected: %v", err)
+	}
+	if err := ValidatePayload("clavain", "dispatch", map[string]any{"schema": "2"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected the v2 rules to reject, got %v", err)
+	}
+	var verr *ValidationError
+	err := ValidatePayload("clavain", "dispatch", map[string]any{"schema": "3"})
+	if !errors.As(err, &verr) || verr.Field != SchemaField {
+		t.Fatalf("expected an unknown schema version error, got %v", err)
+	}
+
+	// Until a version is registered for a pair, schema is an ordinary field.
+	if err := ValidatePayload("interlock", "coordination_signal", map[string]any{
+		"schema": "9", "layer": "l", "icon": "i", "text": "t", "priority": 1.0, "ts": "now",
+	}); err != nil {
+		t.Fatalf("schema field on an unversioned type should be ignored: %v", err)
+	}
+
+	RegisterSchemaValidator("clavain", "dispatch", "2", nil)
+	if err := ValidatePayload("clavain", "dispatch", map[string]any{"schema": "2"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("after unregistering, built-in rules should apply again, got %v", err)
+	}
+}
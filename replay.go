@@ -0,0 +1,82 @@
+package interband
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ReplayChannel copies the envelopes of src into dst, oldest first, and
+// returns how many were written. Each envelope passes through transform
+// (nil copies it unchanged), which may rewrite it or return nil to drop it.
+// Results must belong to dst's namespace and are validated and written as
+// WriteEnvelope would, under the source key; envelopes from a bundle get the
+// bundle key plus "-" and their position. Unreadable source files are skipped
+// as in ListChannel. Transform and write failures are returned joined without
+// stopping the replay.
+func ReplayChannel(src, dst ChannelRef, transform func(Envelope) (*Envelope, error)) (int, error) {
+	return envClient().ReplayChannel(src, dst, transform)
+}
+
+func (c *Client) ReplayChannel(src, dst ChannelRef, transform func(Envelope) (*Envelope, error)) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(src.Namespace, src.Channel)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.ChannelDir(dst.Namespace, dst.Channel); err != nil {
+		return 0, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var items []keyedEnvelope
+	for _, entry := range entries {
+		envs, err := c.ReadBundle(entry.path)
+		if err != nil {
+			continue
+		}
+		for idx, env := range envs {
+			key := entry.key
+			if len(envs) > 1 {
+				key += "-" + strconv.Itoa(idx)
+			}
+			items = append(items, keyedEnvelope{key: key, seq: idx, at: parseTimestamp(env.Timestamp), env: env})
+		}
+	}
+	sortKeyed(items)
+
+	written := 0
+	var errs []error
+	for _, item := range items {
+		out := &item.env
+		if transform != nil {
+			if out, err = transform(item.env); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", item.key, err))
+				continue
+			}
+			if out == nil {
+				continue
+			}
+		}
+		if out.Namespace != dst.Namespace {
+			errs = append(errs, fmt.Errorf("%s: %w", item.key,
+				invalidEnvelope("namespace", "namespace %q does not match destination %q", out.Namespace, dst.Namespace)))
+			continue
+		}
+		p, err := c.Path(dst.Namespace, dst.Channel, item.key)
+		if err == nil {
+			err = c.WriteEnvelope(p, *out)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.key, err))
+			continue
+		}
+		written++
+	}
+	return written, errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplayChannel(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	base := time.Now().Add(-time.Minute)
+	for idx, activity := range []string{"idle", "busy", "busy"} {
+		key := string(rune('a' + idx))
+		env, err := NewEnvelope("clavain", "dispatch").Session(key).At(base.Add(time.Duration(idx)*time.Second)).
+			Set("name", key).Set("workdir", "/w").Set("activity", activity).
+			Set("started", 1).Set("turns", 0).Set("commands", 0).Set("messages", 0).Build()
+		if err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+		p, _ := Path("clavain", "dispatch", key)
+		if err := WriteEnvelope(p, env); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	src := ChannelRef{Namespace: "clavain", Channel: "dispatch"}
+	active := ChannelRef{Namespace: "clavain", Channel: "active"}
+	n, err := ReplayChannel(src, active, func(env Envelope) (*Envelope, error) {
+		if env.Payload["activity"] != "busy" {
+			return nil, nil
+		}
+		env.Type = "active_dispatch"
+		return &env, nil
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 replayed envelopes, got %d %v", n, err)
+	}
+	envs, _, _ := ListChannel("clavain", "active")
+	if len(envs) != 2 || envs[0].Type != "active_dispatch" || envs[0].SessionID != "b" {
+		t.Fatalf("unexpected derived channel: %+v", envs)
+	}
+	if ok, _ := Exists("clavain", "active", "a"); ok {
+		t.Fatal("dropped envelope was written")
+	}
+
+	if n, err := ReplayChannel(src, ChannelRef{Namespace: "copy", Channel: "dispatch"}, nil); n != 0 || !errors.Is(err, ErrValidation) {
+		t.Fatalf("namespace mismatch should fail validation, got %d %v", n, err)
+	}
+	breakIt := func(env Envelope) (*Envelope, error) {
+		delete(env.Payload, "name")
+		return &env, nil
+	}
+	if n, err := ReplayChannel(src, active, breakIt); n != 0 || !errors.Is(err, ErrValidation) {
+		t.Fatalf("invalid results should not be written, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,234 @@
+package interband
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// fieldKind is the constraint a built-in payload field must satisfy.
+type fieldKind int
+
+const (
+	fieldString      fieldKind = iota + 1 // required, non-blank string
+	fieldOptString                        // string or null when present
+	fieldPhase                            // required, one of allowedPhases
+	fieldNumber                           // required number
+	fieldNonNegative                      // required number >= 0
+)
+
+type fieldSpec struct {
+	name string
+	kind fieldKind
+}
+
+// payloadSpec describes a built-in message type. It drives both
+// validatePayload and the JSON Schemas from SchemaFor, so the two cannot
+// drift. Fields are checked in order.
+type payloadSpec struct {
+	namespace string
+	typ       string
+	fields    []fieldSpec
+}
+
+var payloadSpecs = []payloadSpec{
+	{namespace: "interphase", typ: "bead_phase", fields: []fieldSpec{
+		{"id", fieldString},
+		{"phase", fieldPhase},
+		{"reason", fieldOptString},
+		{"ts", fieldNumber},
+	}},
+	{namespace: "clavain", typ: "dispatch", fields: []fieldSpec{
+		{"name", fieldString},
+		{"workdir", fieldString},
+		{"activity", fieldString},
+		{"started", fieldNonNegative},
+		{"turns", fieldNonNegative},
+		{"commands", fieldNonNegative},
+		{"messages", fieldNonNegative},
+	}},
+	{namespace: "interlock", typ: "coordination_signal", fields: []fieldSpec{
+		{"layer", fieldString},
+		{"icon", fieldString},
+		{"text", fieldString},
+		{"ts", fieldString},
+		{"priority", fieldNonNegative},
+	}},
+}
+
+var (
+	closedMu    sync.RWMutex
+	closedSpecs = map[string]bool{}
+)
+
+// SetClosedSchema opts a built-in message type into rejecting payload fields
+// its schema does not list, catching typos such as "messags" that an open
+// schema ignores. Built-in types are open by default, and custom types are
+// always open. It is safe to call from init and concurrently with validation,
+// and applies to reads as well as writes.
+func SetClosedSchema(namespace, typ string, closed bool) error {
+	if _, ok := lookupSpec(namespace, typ); !ok {
+		return fmt.Errorf("no built-in schema for %s/%s", namespace, typ)
+	}
+	closedMu.Lock()
+	defer closedMu.Unlock()
+	if closed {
+		closedSpecs[namespace+":"+typ] = true
+	} else {
+		delete(closedSpecs, namespace+":"+typ)
+	}
+	return nil
+}
+
+func (s payloadSpec) closed() bool {
+	closedMu.RLock()
+	defer closedMu.RUnlock()
+	return closedSpecs[s.namespace+":"+s.typ]
+}
+
+func lookupSpec(namespace, typ string) (payloadSpec, bool) {
+	for _, spec := range payloadSpecs {
+		if spec.namespace == namespace && spec.typ == typ {
+			return spec, true
+		}
+	}
+	return payloadSpec{}, false
+}
+
+// validate checks payload against the spec. With lenientPhases set, an
+// unknown bead phase is logged instead of rejected.
+func (s payloadSpec) validate(payload map[string]any, lenientPhases bool) error {
+	for _, f := range s.fields {
+		v, exists := payload[f.name]
+		switch f.kind {
+		case fieldString:
+			if !isNonEmptyString(v) {
+				return invalidPayload(s.namespace, s.typ, f.name, "%s must be a non-empty string", f.name)
+			}
+		case fieldOptString:
+			if exists && v != nil {
+				if _, ok := v.(string); !ok {
+					return invalidPayload(s.namespace, s.typ, f.name, "%s must be a string", f.name)
+				}
+			}
+		case fieldPhase:
+			phase, ok := v.(string)
+			if !ok || phase == "" {
+				return invalidPayload(s.namespace, s.typ, f.name, "%s must be a non-empty string", f.name)
+			}
+			if _, ok := allowedPhases[phase]; !ok {
+				if !lenientPhases {
+					return invalidPayload(s.namespace, s.typ, f.name, "unknown phase %q", phase)
+				}
+				logWarn("interband: unknown bead phase", "phase", phase, "id", payload["id"])
+			}
+		case fieldNumber:
+			if !isNumber(v) {
+				return invalidPayload(s.namespace, s.typ, f.name, "%s must be numeric", f.name)
+			}
+		case fieldNonNegative:
+			if !isNonNegativeNumber(v) {
+				return invalidPayload(s.namespace, s.typ, f.name, "%s must be a non-negative number", f.name)
+			}
+		}
+	}
+	if s.closed() {
+		return s.checkUnknown(payload)
+	}
+	return nil
+}
+
+// checkUnknown fails on the first payload key, in sorted order, that the spec
+// does not list.
+func (s payloadSpec) checkUnknown(payload map[string]any) error {
+	known := make(map[string]bool, len(s.fields))
+	for _, f := range s.fields {
+		known[f.name] = true
+	}
+	keys := make([]string, 0, len(payload))
+	for k := range payload {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !known[k] {
+			return invalidPayload(s.namespace, s.typ, k, "unexpected field %q", k)
+		}
+	}
+	return nil
+}
+
+// schema renders the spec as a JSON Schema (draft 2020-12) object. Extra
+// payload fields are allowed unless the type is closed, matching the
+// validator.
+func (s payloadSpec) schema() map[string]any {
+	props := make(map[string]any, len(s.fields))
+	required := []string{}
+	for _, f := range s.fields {
+		var prop map[string]any
+		switch f.kind {
+		case fieldString:
+			prop = map[string]any{"type": "string", "pattern": `\S`}
+		case fieldOptString:
+			prop = map[string]any{"type": []string{"string", "null"}}
+		case fieldPhase:
+			prop = map[string]any{"type": "string", "enum": sortedPhases()}
+		case fieldNumber:
+			prop = map[string]any{"type": "number"}
+		case fieldNonNegative:
+			prop = map[string]any{"type": "number", "minimum": 0}
+		}
+		props[f.name] = prop
+		if f.kind != fieldOptString {
+			required = append(required, f.name)
+		}
+	}
+	out := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      s.namespace + "/" + s.typ,
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+	if s.closed() {
+		out["additionalProperties"] = false
+	}
+	return out
+}
+
+func sortedPhases() []string {
+	out := make([]string, 0, len(allowedPhases))
+	for phase := range allowedPhases {
+		out = append(out, phase)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SchemaFor returns the JSON Schema (draft 2020-12) for a built-in message
+// type's payload, generated from the same description the Go validator uses.
+// Types checked by RegisterValidator have no schema.
+func SchemaFor(namespace, typ string) (json.RawMessage, bool) {
+	spec, ok := lookupSpec(namespace, typ)
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(spec.schema())
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// AllSchemas returns the schema of every built-in message type, keyed by
+// "namespace/type".
+func AllSchemas() map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(payloadSpecs))
+	for _, spec := range payloadSpecs {
+		if data, ok := SchemaFor(spec.namespace, spec.typ); ok {
+			out[spec.namespace+"/"+spec.typ] = data
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package interband
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type testSchema struct {
+	Schema     string                    `json:"$schema"`
+	Type       string                    `json:"type"`
+	Required   []string                  `json:"required"`
+	Properties map[string]map[string]any `json:"properties"`
+}
+
+func TestSchemasMatchValidator(t *testing.T) {
+	valid := map[string]map[string]any{
+		"interphase/bead_phase":         {"id": "iv-1", "phase": "planned", "reason": "r", "ts": 1},
+		"clavain/dispatch":              {"name": "n", "workdir": "/w", "activity": "a", "started": 1, "turns": 0, "commands": 0, "messages": 0},
+		"interlock/coordination_signal": {"layer": "l", "icon": "i", "text": "t", "ts": "now", "priority": 1},
+	}
+	all := AllSchemas()
+	if len(all) != len(valid) {
+		t.Fatalf("expected %d schemas, got %d", len(valid), len(all))
+	}
+	for name, payload := range valid {
+		spec := payloadSpecs[0]
+		for _, s := range payloadSpecs {
+			if s.namespace+"/"+s.typ == name {
+				spec = s
+			}
+		}
+		raw, ok := SchemaFor(spec.namespace, spec.typ)
+		if !ok || string(raw) != string(all[name]) {
+			t.Fatalf("%s: SchemaFor and AllSchemas disagree", name)
+		}
+		var schema testSchema
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			t.Fatalf("%s: invalid schema JSON: %v", name, err)
+		}
+		if schema.Schema != "https://json-schema.org/draft/2020-12/schema" || schema.Type != "object" {
+			t.Fatalf("%s: unexpected schema header: %+v", name, schema)
+		}
+		if err := ValidatePayload(spec.namespace, spec.typ, payload); err != nil {
+			t.Fatalf("%s: sample rejected: %v", name, err)
+		}
+		for _, field := range schema.Required {
+			trimmed := map[string]any{}
+			for k, v := range payload {
+				if k != field {
+					trimmed[k] = v
+				}
+			}
+			if err := ValidatePayload(spec.namespace, spec.typ, trimmed); !errors.Is(err, ErrValidation) {
+				t.Fatalf("%s: schema requires %q but validator accepts its absence", name, field)
+			}
+		}
+		if len(schema.Properties) != len(spec.fields) {
+			t.Fatalf("%s: schema has %d properties, spec has %d", name, len(schema.Properties), len(spec.fields))
+		}
+	}
+
+	var bead testSchema
+	raw, _ := SchemaFor("interphase", "bead_phase")
+	_ = json.Unmarshal(raw, &bead)
+	if enum, _ := bead.Properties["phase"]["enum"].([]any); len(enum) != len(allowedPhases) {
+		t.Fatalf("phase enum out of sync: %v", bead.Properties["phase"])
+	}
+	if _, ok := SchemaFor("custom", "events"); ok {
+		t.Fatal("unexpected schema for unknown type")
+	}
+}
+
+func TestClosedSchemaRejectsStrayFields(t *testing.T) {
+	payload := map[string]any{"name": "n", "workdir": "/w", "activity": "a", "started": 1, "turns": 0, "commands": 0, "messages": 0, "messags": 1}
+	if err := ValidatePayload("clavain", "dispatch", payload); err != nil {
+		t.Fatalf("open schema should ignore extra fields: %v", err)
+	}
+
+	if err := SetClosedSchema("clavain", "dispatch", true); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	t.Cleanup(func() { _ = SetClosedSchema("clavain", "dispatch", false) })
+
+	err := ValidatePayload("clavain", "dispatch", payload)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "messags" {
+		t.Fatalf("expected stray field to be rejected, got %v", err)
+	}
+	delete(payload, "messags")
+	if err := ValidatePayload("clavain", "dispatch", payload); err != nil {
+		t.Fatalf("exact payload should pass: %v", err)
+	}
+	if err := ValidatePayload("interlock", "coordination_signal", map[string]any{"layer": "l", "icon": "i", "text": "t", "ts": "now", "priority": 1, "extra": true}); err != nil {
+		t.Fatalf("other types should stay open: %v", err)
+	}
+
+	raw, _ := SchemaFor("clavain", "dispatch")
+	var schema map[string]any
+	_ = json.Unmarshal(raw, &schema)
+	if schema["additionalProperties"] != false {
+		t.Fatalf("closed schema should forbid additional properties: %s", raw)
+	}
+
+	if err := SetClosedSchema("custom", "anything", true); err == nil {
+		t.Fatal("expected error for a type without a built-in schema")
+	}
+}
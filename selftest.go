@@ -0,0 +1,62 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// selfTestDir is the reserved directory under Root that SelfTest writes in.
+const selfTestDir = ReservedPrefix + "-selftest"
+
+// SelfTest checks that the client can write, read back, and remove a message
+// under Root, catching unwritable or read-only mounts before real traffic
+// does, for use as a readiness probe. It writes one small envelope to a
+// reserved directory that Namespaces skips and removes it, and the directory
+// when empty, whether or not the test passes. A read-only client only checks
+// that Root can be listed. Errors name the step that failed.
+func SelfTest() error {
+	return envClient().SelfTest()
+}
+
+func (c *Client) SelfTest() error {
+	b := c.backend()
+	root := c.cfg.Root
+	if c.cfg.ReadOnly {
+		if _, err := b.ReadDir(root); err != nil {
+			return fmt.Errorf("interband self-test: list root: %w", err)
+		}
+		return nil
+	}
+
+	dir := filepath.Join(root, selfTestDir)
+	nonce := strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	p := filepath.Join(dir, nonce+".json")
+	defer func() {
+		_ = b.Remove(p)
+		_ = b.Remove(dir) // only succeeds once empty
+	}()
+
+	env, err := c.newEnvelope(p, "interband", "selftest", "", map[string]any{"nonce": nonce})
+	if err != nil {
+		return fmt.Errorf("interband self-test: build envelope: %w", err)
+	}
+	if err := c.writeEnvelope(context.Background(), p, env); err != nil {
+		return fmt.Errorf("interband self-test: write %s: %w", p, err)
+	}
+	got, err := c.ReadEnvelope(p)
+	if err != nil {
+		return fmt.Errorf("interband self-test: read %s: %w", p, err)
+	}
+	if got.Payload["nonce"] != nonce {
+		return fmt.Errorf("interband self-test: read back %s: payload %v does not match what was written", p, got.Payload)
+	}
+	if err := b.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("interband self-test: remove %s: %w", p, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("INTERBAND_ROOT", root)
+
+	if err := SelfTest(); err != nil {
+		t.Fatalf("self-test failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Fatalf("self-test left residue: %v", entries)
+	}
+
+	c, mem := newMemClient(t)
+	if err := c.SelfTest(); err != nil {
+		t.Fatalf("mem self-test failed: %v", err)
+	}
+	if _, err := mem.ReadDir("/mem"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("mem self-test left residue: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Root = root
+	cfg.ReadOnly = true
+	if err := NewClient(cfg).SelfTest(); err != nil {
+		t.Fatalf("read-only self-test failed: %v", err)
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced")
+	}
+	locked := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(locked, 0o500); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	t.Setenv("INTERBAND_ROOT", locked)
+	if err := SelfTest(); err == nil {
+		t.Fatal("expected self-test to fail on an unwritable root")
+	}
+}
@@ -0,0 +1,45 @@
+package interband
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	processSessionOnce sync.Once
+	processSession     string
+)
+
+// SessionID returns an identifier for this process, built from the host name,
+// the process ID, and the time of the first call, e.g. "build-7-4212-lx2k9c".
+// It is computed once and stable for the life of the process. Clients with
+// AutoSessionID set (INTERBAND_AUTO_SESSION_ID=1) stamp it on envelopes
+// written with an empty session ID.
+func SessionID() string {
+	processSessionOnce.Do(func() {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "unknown"
+		}
+		processSession = SafeKey(host) + "-" + strconv.Itoa(os.Getpid()) + "-" +
+			strconv.FormatInt(time.Now().Unix(), 36)
+	})
+	return processSession
+}
+
+// sessionOrDefault fills an empty session ID from the namespace's registered
+// SessionID func, or when the client is configured to.
+func (c *Client) sessionOrDefault(namespace, sessionID string) string {
+	if sessionID != "" {
+		return sessionID
+	}
+	if spec, ok := registeredNamespace(namespace); ok && spec.SessionID != nil {
+		return spec.SessionID()
+	}
+	if c.cfg.AutoSessionID {
+		return SessionID()
+	}
+	return sessionID
+}
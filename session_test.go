@@ -0,0 +1,40 @@
+package interband
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoSessionID(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	id := SessionID()
+	if id == "" || id != SessionID() || strings.ContainsAny(id, "/ ") {
+		t.Fatalf("unexpected process session: %q", id)
+	}
+
+	p, _ := Path("custom", "events", "k")
+	if err := Write(p, "custom", "anything", "", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if env, _ := ReadEnvelope(p); env.SessionID != "" {
+		t.Fatalf("session should stay empty by default, got %q", env.SessionID)
+	}
+
+	t.Setenv("INTERBAND_AUTO_SESSION_ID", "1")
+	if err := Write(p, "custom", "anything", "", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if env, _ := ReadEnvelope(p); env.SessionID != id {
+		t.Fatalf("expected process session %q, got %q", id, env.SessionID)
+	}
+	if err := Write(p, "custom", "anything", "explicit", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if env, _ := ReadEnvelope(p); env.SessionID != "explicit" {
+		t.Fatalf("explicit session overwritten: %q", env.SessionID)
+	}
+	if env, _ := NewEnvelope("custom", "anything").Build(); env.SessionID != id {
+		t.Fatalf("builder should use process session, got %q", env.SessionID)
+	}
+}
@@ -0,0 +1,130 @@
+package interband
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// signaturePrefix names the algorithm in stored signatures, so another can be
+// added without ambiguity.
+const signaturePrefix = "hmac-sha256:"
+
+// WriteSigned writes a message for key as WriteKey does and stores an
+// HMAC-SHA256 of the envelope, keyed with secret, in its signature field. The
+// MAC also covers the channel, and the envelope records its namespace and
+// key, so a signed file copied to another key, channel, or namespace no
+// longer verifies. ReadEnvelopeVerified checks it.
+func WriteSigned(namespace, channel, key, typ, sessionID string, payload map[string]any, secret []byte) error {
+	return envClient().WriteSigned(namespace, channel, key, typ, sessionID, payload, secret)
+}
+
+func (c *Client) WriteSigned(namespace, channel, key, typ, sessionID string, payload map[string]any, secret []byte) error {
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return err
+	}
+	env, err := c.newEnvelope(p, namespace, typ, sessionID, payload)
+	if err != nil {
+		return err
+	}
+	env.Key = key
+	sum, err := envelopeMAC(env, channel, secret)
+	if err != nil {
+		return err
+	}
+	env.Signature = signaturePrefix + hex.EncodeToString(sum)
+	return c.writeEnvelope(context.Background(), p, env)
+}
+
+// ReadEnvelopeVerified reads key's message, plain or compressed, like
+// ReadEnvelope, then checks that its signature is valid under secret for this
+// namespace, channel, and key. An unsigned envelope fails with ErrUnsigned,
+// and a tampered, differently keyed, or relocated one with
+// ErrSignatureInvalid.
+func ReadEnvelopeVerified(namespace, channel, key string, secret []byte) (Envelope, error) {
+	return envClient().ReadEnvelopeVerified(namespace, channel, key, secret)
+}
+
+func (c *Client) ReadEnvelopeVerified(namespace, channel, key string, secret []byte) (Envelope, error) {
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return Envelope{}, err
+	}
+	_, sourcePath, err := c.statKeyFile(p)
+	if err != nil {
+		return Envelope{}, err
+	}
+	env, err := c.ReadEnvelope(sourcePath)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if env.Signature == "" {
+		return Envelope{}, fmt.Errorf("%s: %w", sourcePath, ErrUnsigned)
+	}
+	if env.Namespace != namespace || env.Key != key {
+		return Envelope{}, fmt.Errorf("%s: signed for %s key %q: %w", sourcePath, env.Namespace, env.Key, ErrSignatureInvalid)
+	}
+	if err := verifyMAC(env, channel, secret); err != nil {
+		return Envelope{}, fmt.Errorf("%s: %w", sourcePath, err)
+	}
+	return env, nil
+}
+
+// SignEnvelope returns env with its signature set to the HMAC-SHA256 of its
+// canonical encoding under key. Any existing signature is replaced. It is for
+// envelopes that are not stored in a channel; WriteSigned also binds the
+// channel.
+func SignEnvelope(env Envelope, key []byte) (Envelope, error) {
+	sum, err := envelopeMAC(env, "", key)
+	if err != nil {
+		return Envelope{}, err
+	}
+	env.Signature = signaturePrefix + hex.EncodeToString(sum)
+	return env, nil
+}
+
+// VerifyEnvelope checks a SignEnvelope signature against key, failing with
+// ErrUnsigned or ErrSignatureInvalid.
+func VerifyEnvelope(env Envelope, key []byte) error {
+	if env.Signature == "" {
+		return ErrUnsigned
+	}
+	return verifyMAC(env, "", key)
+}
+
+func verifyMAC(env Envelope, channel string, key []byte) error {
+	want, err := envelopeMAC(env, channel, key)
+	if err != nil {
+		return err
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(env.Signature, signaturePrefix))
+	if err != nil || !strings.HasPrefix(env.Signature, signaturePrefix) || !hmac.Equal(got, want) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// envelopeMAC is the HMAC of env's canonical encoding, wrapped together with
+// channel when one is given.
+func envelopeMAC(env Envelope, channel string, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("signing key is required")
+	}
+	env.Signature = ""
+	var signed any = env
+	if channel != "" {
+		signed = map[string]any{"channel": channel, "envelope": env}
+	}
+	data, err := CanonicalJSON(signed)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,108 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteSignedReadVerified(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	c := NewClient(cfg)
+	secret := []byte("shared-secret")
+
+	payload := map[string]any{"owner": "agent-1", "ttl": 30, "tags": map[string]any{"z": 1, "a": "<b>"}}
+	if err := c.WriteSigned("interlock", "coordination", "agent-1", "lease", "s", payload, secret); err != nil {
+		t.Fatalf("signed write failed: %v", err)
+	}
+	env, err := c.ReadEnvelopeVerified("interlock", "coordination", "agent-1", secret)
+	if err != nil {
+		t.Fatalf("verified read failed: %v", err)
+	}
+	if env.Payload["owner"] != "agent-1" || env.Key != "agent-1" {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+	if _, err := c.ReadEnvelopeVerified("interlock", "coordination", "agent-1", []byte("other")); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected ErrSignatureInvalid for the wrong secret, got %v", err)
+	}
+
+	p, _ := c.Path("interlock", "coordination", "agent-1")
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), `"ttl":30`, `"ttl":3000`, 1)
+	if tampered == string(data) {
+		t.Fatalf("test payload not found in %s", data)
+	}
+	if err := os.WriteFile(p, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadEnvelopeVerified("interlock", "coordination", "agent-1", secret); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected ErrSignatureInvalid after tampering, got %v", err)
+	}
+
+	if err := c.Write(p, "interlock", "lease", "s", payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := c.ReadEnvelopeVerified("interlock", "coordination", "agent-1", secret); !errors.Is(err, ErrUnsigned) || errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected ErrUnsigned, got %v", err)
+	}
+}
+
+func TestSignedEnvelopesDoNotVerifyElsewhere(t *testing.T) {
+	c, mem := newMemClient(t)
+	secret := []byte("shared-secret")
+	if err := c.WriteSigned("interlock", "coordination", "agent-1", "lease", "s", map[string]any{"owner": "agent-1"}, secret); err != nil {
+		t.Fatalf("signed write failed: %v", err)
+	}
+	src, _ := c.Path("interlock", "coordination", "agent-1")
+	data, err := mem.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Someone with write access copies the valid file to another key and
+	// another channel.
+	for _, dst := range []struct{ channel, key string }{{"coordination", "agent-2"}, {"other", "agent-1"}} {
+		p, _ := c.Path("interlock", dst.channel, dst.key)
+		if err := mem.WriteAtomic(context.Background(), p, data); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.ReadEnvelopeVerified("interlock", dst.channel, dst.key, secret); !errors.Is(err, ErrSignatureInvalid) {
+			t.Fatalf("copy to %s/%s: expected ErrSignatureInvalid, got %v", dst.channel, dst.key, err)
+		}
+	}
+}
+
+func TestSignEnvelopeCanonical(t *testing.T) {
+	key := []byte("k")
+	env := Envelope{Version: "1.0.0", Namespace: "custom", Type: "t", Timestamp: "2026-01-01T00:00:00Z",
+		Payload: map[string]any{"n": 1, "nested": map[string]any{"b": 2, "a": []any{int64(3)}}}}
+	signed, err := SignEnvelope(env, key)
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	// The read-back form has float64 numbers; the signature must still hold.
+	decoded, err := UnmarshalEnvelope(mustEncode(t, signed))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if err := VerifyEnvelope(decoded, key); err != nil {
+		t.Fatalf("verify after round trip failed: %v", err)
+	}
+	if _, err := SignEnvelope(env, nil); err == nil {
+		t.Fatal("expected an empty key to be rejected")
+	}
+}
+
+func mustEncode(t *testing.T, env Envelope) []byte {
+	t.Helper()
+	data, err := encodeEnvelope(env)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	return data
+}
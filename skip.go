@@ -0,0 +1,45 @@
+package interband
+
+import "sync/atomic"
+
+// SkipHandler is told about each file a listing or iteration skips instead of
+// failing. errors.Is on err tells the cause apart: ErrMalformed for JSON that
+// does not decode (such as a truncated file), ErrValidation for an envelope
+// that breaks its contract, ErrUnsupportedVersion for a version the reader
+// rejects, and anything else, typically an IO error.
+type SkipHandler func(path string, err error)
+
+type skipBox struct{ fn SkipHandler }
+
+var globalSkipHandler atomic.Pointer[skipBox]
+
+// SetSkipHandler installs the handler used by the package-level functions and
+// by clients whose Config.OnSkip is nil. Nil restores the default of skipping
+// silently.
+func SetSkipHandler(fn SkipHandler) {
+	if fn == nil {
+		globalSkipHandler.Store(nil)
+		return
+	}
+	globalSkipHandler.Store(&skipBox{fn: fn})
+}
+
+// skip reports a skipped file to the client's skip handler, if any, recovering
+// panics as observe does.
+func (c *Client) skip(path string, err error) {
+	fn := c.cfg.OnSkip
+	if fn == nil {
+		if box := globalSkipHandler.Load(); box != nil {
+			fn = box.fn
+		}
+	}
+	if fn == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logWarn("interband: skip handler panicked", "panic", r)
+		}
+	}()
+	fn(path, err)
+}
@@ -0,0 +1,116 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type skipLog struct {
+	mu   sync.Mutex
+	errs map[string][]error
+}
+
+func (l *skipLog) record(path string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs[filepath.Base(path)] = append(l.errs[filepath.Base(path)], err)
+}
+
+func (l *skipLog) get(name string) []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.errs[name]
+}
+
+func TestSkipHandlerClassifiesCauses(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	log := &skipLog{errs: map[string][]error{}}
+	cfg.OnSkip = log.record
+	c := NewClient(cfg)
+
+	good, err := c.Path("custom", "events", "good")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := c.Write(good, "custom", "anything", "s", map[string]any{"k": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	dir := filepath.Dir(good)
+	if err := os.WriteFile(filepath.Join(dir, "truncated.json"), []byte(`{"version":"1.0.0","namesp`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`{"version":"1.0.0","namespace":"","type":"x","timestamp":"2026-01-01T00:00:00Z","payload":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	envs, skipped, err := c.ListChannel("custom", "events")
+	if err != nil || len(envs) != 1 || len(skipped) != 2 {
+		t.Fatalf("list: %d envelopes, %d skipped, %v", len(envs), len(skipped), err)
+	}
+	trunc, invalid := log.get("truncated.json"), log.get("invalid.json")
+	if len(trunc) != 1 || !errors.Is(trunc[0], ErrMalformed) || errors.Is(trunc[0], ErrValidation) {
+		t.Fatalf("truncated file reported as %v", trunc)
+	}
+	if len(invalid) != 1 || !errors.Is(invalid[0], ErrValidation) || errors.Is(invalid[0], ErrMalformed) {
+		t.Fatalf("invalid envelope reported as %v", invalid)
+	}
+
+	it, err := c.NewChannelIterator("custom", "events")
+	if err != nil {
+		t.Fatalf("iterator: %v", err)
+	}
+	for it.Next() {
+	}
+	if got := len(log.get("truncated.json")); got != 2 {
+		t.Fatalf("iterator should report the truncated file again, got %d reports", got)
+	}
+}
+
+func TestSkipHandlerTailReportsOnce(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.PollInterval = 5 * time.Millisecond
+	log := &skipLog{errs: map[string][]error{}}
+	cfg.OnSkip = log.record
+	c := NewClient(cfg)
+
+	dir, err := c.ChannelDir("custom", "events")
+	if err != nil {
+		t.Fatalf("channel dir: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "truncated.json"), []byte(`{"ver`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Tail(ctx, "custom", "events", func(Envelope) error { return nil }); err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if got := log.get("truncated.json"); len(got) != 1 || !errors.Is(got[0], ErrMalformed) {
+		t.Fatalf("expected one malformed report across polls, got %v", got)
+	}
+}
+
+func TestSetSkipHandler(t *testing.T) {
+	var got []string
+	SetSkipHandler(func(path string, err error) { got = append(got, path) })
+	defer SetSkipHandler(nil)
+
+	c, _ := newMemClient(t)
+	c.skip("a.json", ErrMalformed)
+	c.cfg.OnSkip = func(string, error) { panic("boom") }
+	c.skip("b.json", ErrMalformed)
+	if len(got) != 1 || got[0] != "a.json" {
+		t.Fatalf("global handler saw %v", got)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package interband
+
+import "os"
+
+// syncDir flushes dir's entries so a completed rename survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
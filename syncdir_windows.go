@@ -0,0 +1,5 @@
+package interband
+
+// syncDir is a no-op on Windows, where directories cannot be fsynced and
+// MoveFileEx already persists the rename.
+func syncDir(string) error { return nil }
@@ -0,0 +1,91 @@
+package interband
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Update is the read-modify-write primitive for state-like channels. Under the
+// channel lock it reads the payload stored for key (an empty map if there is
+// none, or if it has expired and the client honors expiry), passes a copy to
+// fn, validates the result as typ, and writes it atomically, compressed if the
+// stored value was. If the stored bytes change between the read and the write,
+// which only writers that skip the lock can cause, Update fails with
+// ErrConflict and writes nothing; callers may simply retry.
+func Update(namespace, channel, key, typ, sessionID string, fn func(map[string]any) (map[string]any, error)) error {
+	return envClient().Update(namespace, channel, key, typ, sessionID, fn)
+}
+
+func (c *Client) Update(namespace, channel, key, typ, sessionID string, fn func(map[string]any) (map[string]any, error)) error {
+	if err := c.writable(); err != nil {
+		return err
+	}
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return err
+	}
+	return c.WithChannelLock(namespace, channel, func() error {
+		target, before, err := c.readForUpdate(p)
+		if err != nil {
+			return err
+		}
+		current := map[string]any{}
+		if before != nil {
+			// An expired value counts as no value, so it does not block
+			// every later update.
+			env, err := c.UnmarshalEnvelope(before)
+			if err == nil {
+				err = c.finishRead(target, env, len(before))
+			}
+			if err != nil && !errors.Is(err, ErrExpired) {
+				return err
+			}
+			if err == nil {
+				for k, v := range env.Payload {
+					current[k] = v
+				}
+			}
+		}
+
+		next, err := fn(current)
+		if err != nil {
+			return err
+		}
+		env, err := c.newEnvelope(target, namespace, typ, sessionID, next)
+		if err != nil {
+			return err
+		}
+		env.Key = key
+
+		afterTarget, after, err := c.readForUpdate(p)
+		if err != nil {
+			return err
+		}
+		if afterTarget != target || !bytes.Equal(before, after) {
+			return fmt.Errorf("%w: %s changed during update", ErrConflict, target)
+		}
+		if strings.HasSuffix(target, CompressedExt) {
+			return c.writeCompressedEnvelope(target, env)
+		}
+		return c.WriteEnvelope(target, env)
+	})
+}
+
+// readForUpdate returns the file holding p's value, plain or compressed, and
+// its decompressed contents. A missing value reads as p with nil contents.
+func (c *Client) readForUpdate(p string) (string, []byte, error) {
+	_, target, err := c.statKeyFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+	data, err := c.readEnvelopeFile(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil, nil
+	}
+	return target, data, err
+}
@@ -0,0 +1,89 @@
+package interband
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestUpdateMergesAndCreates(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	bump := func(payload map[string]any) (map[string]any, error) {
+		n, _ := payload["n"].(float64)
+		payload["n"] = n + 1
+		payload["owner"] = "me"
+		return payload, nil
+	}
+	var wg sync.WaitGroup
+	for idx := 0; idx < 8; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Update("custom", "state", "k", "counter", "s", bump); err != nil {
+				t.Errorf("update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	p, _ := Path("custom", "state", "k")
+	payload, err := ReadPayload(p)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if payload["n"] != float64(8) || payload["owner"] != "me" {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+}
+
+func TestUpdateConflictAndErrors(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, _ := Path("custom", "state", "k")
+	if err := Write(p, "custom", "counter", "s", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	err := Update("custom", "state", "k", "counter", "s", func(payload map[string]any) (map[string]any, error) {
+		// An unlocked writer sneaks in between read and write, leaving a
+		// file of the same size that coarse mtimes could not tell apart.
+		if err := Write(p, "custom", "counter", "s", map[string]any{"n": 9}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		return payload, nil
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	boom := errors.New("boom")
+	if err := Update("custom", "state", "k", "counter", "s", func(map[string]any) (map[string]any, error) { return nil, boom }); !errors.Is(err, boom) {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+	if err := Update("interphase", "bead", "k", "bead_phase", "s", func(p map[string]any) (map[string]any, error) { return p, nil }); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestUpdateKeepsCompressedValues(t *testing.T) {
+	c, mem := newMemClient(t)
+	p, _ := c.Path("custom", "state", "k")
+	if err := c.WriteCompressed(p+CompressedExt, "custom", "counter", "s", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	err := c.Update("custom", "state", "k", "counter", "s", func(payload map[string]any) (map[string]any, error) {
+		n, _ := payload["n"].(float64)
+		payload["n"] = n + 1
+		return payload, nil
+	})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if _, err := mem.Stat(p); err == nil {
+		t.Fatal("update should not add a plain file beside the compressed value")
+	}
+	env, err := c.ReadEnvelope(p + CompressedExt)
+	if err != nil || env.Payload["n"] != float64(2) {
+		t.Fatalf("read after update = %v, %v", env.Payload, err)
+	}
+}
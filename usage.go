@@ -0,0 +1,83 @@
+package interband
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Usage summarizes the storage one channel consumes. It is built from file
+// metadata alone, so Oldest and Newest are file modification times, which
+// Write sets when it stamps the envelope.
+type Usage struct {
+	Files  int
+	Bytes  int64
+	Oldest time.Time
+	Newest time.Time
+}
+
+func (u *Usage) add(entry channelEntry) {
+	u.Files++
+	u.Bytes += entry.size
+	if u.Oldest.IsZero() || entry.modTime.Before(u.Oldest) {
+		u.Oldest = entry.modTime
+	}
+	if entry.modTime.After(u.Newest) {
+		u.Newest = entry.modTime
+	}
+}
+
+// ChannelUsage stats the envelope files in a channel, skipping temp files and
+// the prune stamp. Compressed files count at their on-disk size. A missing
+// channel reports zero usage.
+func ChannelUsage(namespace, channel string) (Usage, error) {
+	return envClient().ChannelUsage(namespace, channel)
+}
+
+func (c *Client) ChannelUsage(namespace, channel string) (Usage, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return Usage{}, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return Usage{}, err
+	}
+	var u Usage
+	for _, entry := range entries {
+		u.add(entry)
+	}
+	return u, nil
+}
+
+// TotalUsage reports ChannelUsage for every channel under Root, continuing
+// past channels that cannot be read.
+func TotalUsage() (map[ChannelRef]Usage, error) {
+	return envClient().TotalUsage()
+}
+
+func (c *Client) TotalUsage() (map[ChannelRef]Usage, error) {
+	out := make(map[ChannelRef]Usage)
+	namespaces, err := c.listSubdirs(c.cfg.Root)
+	if err != nil {
+		return out, err
+	}
+	var errs []error
+	for _, ns := range namespaces {
+		channels, err := c.listSubdirs(filepath.Join(c.cfg.Root, ns))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, ch := range channels {
+			u, err := c.ChannelUsage(ns, ch)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", ns, ch, err))
+				continue
+			}
+			out[ChannelRef{Namespace: ns, Channel: ch}] = u
+		}
+	}
+	return out, errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChannelUsage(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("INTERBAND_ROOT", root)
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+
+	if u, err := ChannelUsage("custom", "empty"); err != nil || u.Files != 0 {
+		t.Fatalf("expected zero usage for missing channel, got %+v %v", u, err)
+	}
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var total int64
+	for idx, key := range []string{"a", "b", "c"} {
+		at := base.Add(time.Duration(idx) * time.Minute)
+		p := writeAt(t, "custom", "events", key, at, map[string]any{"n": idx})
+		if err := os.Chtimes(p, at, at); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+		info, _ := os.Stat(p)
+		total += info.Size()
+	}
+	dir, _ := ChannelDir("custom", "events")
+	if err := PruneChannel("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".interband-tmp.x"), []byte("partial"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	u, err := ChannelUsage("custom", "events")
+	if err != nil {
+		t.Fatalf("usage failed: %v", err)
+	}
+	if u.Files != 3 || u.Bytes != total {
+		t.Fatalf("unexpected usage: %+v (want %d bytes)", u, total)
+	}
+	if !u.Oldest.Equal(base) || !u.Newest.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("unexpected range: %v..%v", u.Oldest, u.Newest)
+	}
+
+	writeAt(t, "other", "x", "k", base, map[string]any{})
+	all, err := TotalUsage()
+	if err != nil || len(all) != 2 {
+		t.Fatalf("unexpected total usage: %v %v", all, err)
+	}
+	if all[ChannelRef{Namespace: "custom", Channel: "events"}] != u {
+		t.Fatalf("total usage disagrees with channel usage: %+v", all)
+	}
+}
@@ -0,0 +1,55 @@
+package interband
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateOption tightens ValidateEnvelope.
+type ValidateOption func(*validateOptions)
+
+// RequireUTC makes ValidateEnvelope reject RFC 3339 timestamps with an offset
+// other than Z or +00:00, so envelopes from several hosts order correctly when
+// merged. Epoch timestamps are UTC by definition and pass.
+func RequireUTC() ValidateOption {
+	return func(o *validateOptions) { o.requireUTC = true }
+}
+
+// checkUTC fails when raw, a parseable timestamp, carries a non-UTC offset.
+func checkUTC(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if _, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return invalidEnvelope("timestamp", "%v", err)
+	}
+	if _, offset := t.Zone(); offset != 0 {
+		return invalidEnvelope("timestamp", "timestamp %q is not UTC", raw)
+	}
+	return nil
+}
+
+// zonelessLayout is RFC 3339 without the zone, which some producers emit.
+const zonelessLayout = "2006-01-02T15:04:05.999999999"
+
+// UTC returns a copy of e with its timestamp rewritten as RFC 3339 in UTC,
+// keeping sub-second precision. Offsets and epoch timestamps are converted,
+// and a timestamp without a zone, which RFC 3339 forbids but some producers
+// emit, is taken to be UTC already. A leap second such as 23:59:60 cannot be
+// represented and fails, as does any other unparseable timestamp, with a
+// *ValidationError.
+func (e Envelope) UTC() (Envelope, error) {
+	t, err := e.Time()
+	if err != nil {
+		zoneless, zerr := time.Parse(zonelessLayout, strings.TrimSpace(e.Timestamp))
+		if zerr != nil {
+			return Envelope{}, invalidEnvelope("timestamp", "%v", err)
+		}
+		t = zoneless
+	}
+	e.Timestamp = t.UTC().Format(time.RFC3339Nano)
+	return e, nil
+}
@@ -0,0 +1,59 @@
+package interband
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequireUTC(t *testing.T) {
+	env := Envelope{Version: "1.0.0", Namespace: "custom", Type: "any", Timestamp: "2026-01-01T09:00:00+09:00", Payload: map[string]any{}}
+	if err := ValidateEnvelope(env); err != nil {
+		t.Fatalf("offsets are valid by default: %v", err)
+	}
+	if err := ValidateEnvelope(env, RequireUTC()); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected non-UTC timestamp rejected, got %v", err)
+	}
+	for _, ts := range []string{"2026-01-01T00:00:00Z", "2026-01-01T00:00:00.5+00:00", "1767225600", "1767225600000"} {
+		env.Timestamp = ts
+		if err := ValidateEnvelope(env, RequireUTC()); err != nil {
+			t.Fatalf("%s: expected UTC accepted, got %v", ts, err)
+		}
+	}
+
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	p, _ := Path("custom", "events", "k")
+	env.Timestamp = "2026-01-01T09:00:00+09:00"
+	if err := WriteEnvelope(p, env); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	t.Setenv("INTERBAND_REQUIRE_UTC", "1")
+	if _, err := ReadEnvelope(p); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected strict reader to reject, got %v", err)
+	}
+	if err := WriteEnvelope(p, env); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected strict WriteEnvelope to reject, got %v", err)
+	}
+	if err := Write(p, "custom", "any", "s", map[string]any{}); err != nil {
+		t.Fatalf("Write stamps UTC and should pass: %v", err)
+	}
+}
+
+func TestEnvelopeUTC(t *testing.T) {
+	cases := map[string]string{
+		"2026-01-01T09:00:00.25+09:00": "2026-01-01T00:00:00.25Z",
+		"2026-01-01T00:00:00Z":         "2026-01-01T00:00:00Z",
+		"1767225600":                   "2026-01-01T00:00:00Z",
+		"2026-01-01T00:00:00":          "2026-01-01T00:00:00Z",
+	}
+	for in, want := range cases {
+		env, err := Envelope{Timestamp: in, Payload: map[string]any{"a": 1}}.UTC()
+		if err != nil || env.Timestamp != want || env.Payload["a"] != 1 {
+			t.Fatalf("%s: got %+v %v, want %s", in, env, err, want)
+		}
+	}
+	for _, bad := range []string{"2026-12-31T23:59:60Z", "yesterday", ""} {
+		if _, err := (Envelope{Timestamp: bad}).UTC(); !errors.Is(err, ErrValidation) {
+			t.Fatalf("%q: expected ErrValidation, got %v", bad, err)
+		}
+	}
+}
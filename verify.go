@@ -0,0 +1,160 @@
+package interband
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+)
+
+// QuarantineDir is the channel subdirectory RepairChannel moves bad files
+// into. Its reserved name keeps it out of listings and pruning.
+const QuarantineDir = ReservedPrefix + "-bad"
+
+// FileCheck is VerifyChannel's verdict on one channel file.
+type FileCheck struct {
+	Path string
+	Key  string
+	// Parsed is set when the file decodes as an envelope or bundle.
+	Parsed bool
+	// TimestampOK is set when every envelope timestamp parses.
+	TimestampOK bool
+	// Valid is set when every envelope passes validation for its declared
+	// namespace and type, as ReadEnvelope would check it.
+	Valid bool
+	// KeyOK is set when the file name is what Path would produce for it,
+	// i.e. sanitizing the key leaves it unchanged.
+	KeyOK bool
+	// Err is the first decode or validation failure.
+	Err error
+}
+
+// OK reports whether the file passed every check.
+func (f FileCheck) OK() bool {
+	return f.Parsed && f.TimestampOK && f.Valid && f.KeyOK
+}
+
+// VerifyReport lists a check for every envelope file in a channel, in
+// directory order.
+type VerifyReport struct {
+	Files []FileCheck
+}
+
+// Bad returns the checks that failed.
+func (r VerifyReport) Bad() []FileCheck {
+	var out []FileCheck
+	for _, f := range r.Files {
+		if !f.OK() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// VerifyChannel checks every envelope file in a channel without modifying
+// anything, to explain why readers skip some of them. The error is only set
+// when the channel itself cannot be read.
+func VerifyChannel(namespace, channel string) (VerifyReport, error) {
+	return envClient().VerifyChannel(namespace, channel)
+}
+
+func (c *Client) VerifyChannel(namespace, channel string) (VerifyReport, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	entries, err := c.readChannelEntries(dir)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	report := VerifyReport{Files: make([]FileCheck, 0, len(entries))}
+	for _, entry := range entries {
+		report.Files = append(report.Files, c.checkFile(entry))
+	}
+	return report, nil
+}
+
+func (c *Client) checkFile(entry channelEntry) FileCheck {
+	check := FileCheck{Path: entry.path, Key: entry.key, KeyOK: c.safeKey(entry.key) == entry.key}
+	data, err := c.readEnvelopeFile(entry.path)
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	var envs []Envelope
+	if isBundle(data) {
+		err = json.Unmarshal(data, &envs)
+	} else {
+		var env Envelope
+		err = json.Unmarshal(data, &env)
+		envs = []Envelope{env}
+	}
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	check.Parsed = true
+	check.TimestampOK = true
+	check.Valid = true
+	for _, env := range envs {
+		if _, err := env.Time(); err != nil {
+			check.TimestampOK = false
+		}
+		if err := validateEnvelope(env, c.readOptions()); err != nil {
+			check.Valid = false
+			if check.Err == nil {
+				check.Err = err
+			}
+		}
+	}
+	return check
+}
+
+// RepairChannel moves files that do not parse or validate into the channel's
+// QuarantineDir subdirectory, under the channel lock, and returns how many
+// were moved. Files whose only problem is their name are left in place.
+// Failures to move individual files are returned joined.
+func RepairChannel(namespace, channel string) (int, error) {
+	return envClient().RepairChannel(namespace, channel)
+}
+
+func (c *Client) RepairChannel(namespace, channel string) (int, error) {
+	if err := c.writable(); err != nil {
+		return 0, err
+	}
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	var errs []error
+	b := c.backend()
+	err = c.WithChannelLock(namespace, channel, func() error {
+		report, err := c.VerifyChannel(namespace, channel)
+		if err != nil {
+			return err
+		}
+		for _, f := range report.Files {
+			if f.Parsed && f.Valid {
+				continue
+			}
+			data, err := b.ReadFile(f.Path)
+			if err == nil {
+				err = b.WriteAtomic(context.Background(), filepath.Join(dir, QuarantineDir, filepath.Base(f.Path)), data)
+			}
+			if err == nil {
+				err = b.Remove(f.Path)
+			}
+			if err != nil {
+				errs = append(errs, FileError{Path: f.Path, Err: err})
+				continue
+			}
+			moved++
+		}
+		return nil
+	})
+	if err != nil {
+		return moved, err
+	}
+	return moved, errors.Join(errs...)
+}
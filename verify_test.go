@@ -0,0 +1,68 @@
+package interband
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyAndRepairChannel(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+
+	good, _ := Path("custom", "events", "good")
+	if err := Write(good, "custom", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	dir := filepath.Dir(good)
+	raw, _ := os.ReadFile(good)
+	files := map[string]string{
+		"garbage.json":  "{not json",
+		"badts.json":    `{"version":"1.0.0","namespace":"custom","type":"anything","timestamp":"yesterday","payload":{}}`,
+		"invalid.json":  `{"version":"1.0.0","namespace":"interphase","type":"bead_phase","timestamp":"2024-01-01T00:00:00Z","payload":{"id":"x"}}`,
+		"bad name.json": string(raw),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	report, err := VerifyChannel("custom", "events")
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	checks := map[string]FileCheck{}
+	for _, f := range report.Files {
+		checks[f.Key] = f
+	}
+	if len(checks) != 5 || len(report.Bad()) != 4 || !checks["good"].OK() {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if c := checks["garbage"]; c.Parsed || c.Err == nil {
+		t.Fatalf("garbage should not parse: %+v", c)
+	}
+	if c := checks["badts"]; !c.Parsed || c.TimestampOK || c.Valid {
+		t.Fatalf("bad timestamp not reported: %+v", c)
+	}
+	if c := checks["invalid"]; !c.TimestampOK || c.Valid {
+		t.Fatalf("invalid payload not reported: %+v", c)
+	}
+	if c := checks["bad name"]; !c.Valid || c.KeyOK {
+		t.Fatalf("bad key not reported: %+v", c)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "garbage.json")); err != nil {
+		t.Fatal("verify must not modify the channel")
+	}
+
+	moved, err := RepairChannel("custom", "events")
+	if err != nil || moved != 3 {
+		t.Fatalf("expected 3 quarantined files, got %d %v", moved, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, QuarantineDir, "garbage.json")); err != nil {
+		t.Fatalf("quarantined file missing: %v", err)
+	}
+	envs, skipped, _ := ListChannel("custom", "events")
+	if len(envs) != 2 || len(skipped) != 0 {
+		t.Fatalf("expected a clean channel, got %d envelopes, %v", len(envs), skipped)
+	}
+}
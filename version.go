@@ -0,0 +1,122 @@
+package interband
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentVersion is the newest protocol version this package understands.
+const CurrentVersion = "1.0.0"
+
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+func parseVersion(raw string) (semver, error) {
+	s := strings.TrimSpace(raw)
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if i == len(s)-1 {
+			return semver{}, fmt.Errorf("malformed version %q", raw)
+		}
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("malformed version %q", raw)
+	}
+	nums := make([]int, 3)
+	for idx, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part == "" || part[0] == '+' {
+			return semver{}, fmt.Errorf("malformed version %q", raw)
+		}
+		nums[idx] = n
+	}
+	v.major, v.minor, v.patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// CompareVersions compares two semantic versions, returning -1, 0, or 1.
+// Build metadata is ignored and pre-releases sort before their release.
+func CompareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for _, pair := range [][2]int{{va.major, vb.major}, {va.minor, vb.minor}, {va.patch, vb.patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c, nil
+		}
+	}
+	return comparePre(va.pre, vb.pre), nil
+}
+
+// CheckVersion reports whether an envelope version is readable: it must be a
+// well-formed 1.x.y version. With strict set, minor versions newer than
+// CurrentVersion are rejected as well.
+func CheckVersion(version string, strict bool) error {
+	v, err := parseVersion(version)
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrUnsupportedVersion, version, err)
+	}
+	cur, _ := parseVersion(CurrentVersion)
+	if v.major != cur.major {
+		return fmt.Errorf("%w %q", ErrUnsupportedVersion, version)
+	}
+	if strict && v.minor > cur.minor {
+		return fmt.Errorf("%w %q: newer than %s", ErrUnsupportedVersion, version, CurrentVersion)
+	}
+	return nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparePre(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if c := compareInts(na, nb); c != 0 {
+				return c
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
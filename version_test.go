@@ -0,0 +1,47 @@
+package interband
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"2.0.0", "1.999.0", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+	}
+	for _, tc := range cases {
+		got, err := CompareVersions(tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("compare %q %q: %v", tc.a, tc.b, err)
+		}
+		if got != tc.want {
+			t.Fatalf("compare %q %q: expected %d, got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+
+	for _, bad := range []string{"", "1", "1.x", "1.0", "1.0.x", "1.-1.0", "v1.0.0", "1.0.0-"} {
+		if _, err := CompareVersions(bad, "1.0.0"); err == nil {
+			t.Fatalf("expected %q to be malformed", bad)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	if err := CheckVersion("1.999.0", false); err != nil {
+		t.Fatalf("expected lenient check to accept newer minor: %v", err)
+	}
+	if err := CheckVersion("1.999.0", true); err == nil {
+		t.Fatal("expected strict check to reject newer minor")
+	}
+	if err := CheckVersion("10.0.0", false); err == nil {
+		t.Fatal("expected major 10 to be rejected")
+	}
+	if err := CheckVersion("1.x", false); err == nil {
+		t.Fatal("expected malformed version to be rejected")
+	}
+}
@@ -0,0 +1,42 @@
+package interband
+
+import (
+	"context"
+	"time"
+)
+
+// WaitForKey blocks until a valid message exists for key, checking every poll
+// (PollInterval if poll is not positive), and returns it. Writers rename
+// complete temp files into place, so a message still being written is never
+// seen; a file that exists but fails to read or validate is retried on the
+// next poll. When ctx ends first, WaitForKey returns ctx.Err().
+func WaitForKey(ctx context.Context, namespace, channel, key string, poll time.Duration) (Envelope, error) {
+	return envClient().WaitForKey(ctx, namespace, channel, key, poll)
+}
+
+func (c *Client) WaitForKey(ctx context.Context, namespace, channel, key string, poll time.Duration) (Envelope, error) {
+	p, err := c.Path(namespace, channel, key)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if poll <= 0 {
+		poll = c.cfg.PollInterval
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		if err := ctx.Err(); err != nil {
+			return Envelope{}, err
+		}
+		if _, file, err := c.statKeyFile(p); err == nil {
+			if env, err := c.ReadEnvelopeContext(ctx, file); err == nil {
+				return env, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return Envelope{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
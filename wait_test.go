@@ -0,0 +1,54 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitForKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	c := NewClient(cfg)
+
+	p, _ := c.Path("custom", "handshake", "ready")
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A writer's temp file and a half-written final file do not count.
+	if err := os.WriteFile(filepath.Join(filepath.Dir(p), tempFilePrefix+"x"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(`{"version":"1.0.0"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = c.Write(p, "custom", "ack", "peer", map[string]any{"ok": true})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	env, err := c.WaitForKey(ctx, "custom", "handshake", "ready", 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if env.Type != "ack" || env.SessionID != "peer" {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+}
+
+func TestWaitForKeyTimeout(t *testing.T) {
+	c, _ := newMemClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForKey(ctx, "custom", "handshake", "never", time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if _, err := c.WaitForKey(ctx, "custom", "../x", "never", time.Millisecond); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for a bad channel, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package interband
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventKind classifies a change observed in a channel directory.
+type EventKind int
+
+const (
+	EventCreated EventKind = iota + 1
+	EventUpdated
+	EventRemoved
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventCreated:
+		return "created"
+	case EventUpdated:
+		return "updated"
+	case EventRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change to one key in a watched channel. Envelope is set for
+// creations and updates; removals only carry the key and path.
+type Event struct {
+	Kind     EventKind
+	Key      string
+	Path     string
+	Envelope *Envelope
+}
+
+// PollInterval is how often watchers rescan a channel directory.
+func PollInterval() time.Duration {
+	if v, ok := parseEnvInt("INTERBAND_POLL_INTERVAL_MS"); ok && v > 0 {
+		return time.Duration(v) * time.Millisecond
+	}
+	return 500 * time.Millisecond
+}
+
+// WatchEvents reports creations, updates, and removals of keys in a channel
+// until ctx is cancelled, at which point the returned channel is closed. Keys
+// present when the watch starts are not reported. A reported key whose
+// envelope expires or whose file becomes unreadable is reported as removed,
+// and as created again once it reads cleanly. Changes are detected by polling
+// the directory every PollInterval. A bundle file is reported with the item
+// ListChannel orders last as its Envelope.
+func WatchEvents(ctx context.Context, namespace, channel string) (<-chan Event, error) {
+	return envClient().WatchEvents(ctx, namespace, channel)
+}
+
+func (c *Client) WatchEvents(ctx context.Context, namespace, channel string) (<-chan Event, error) {
+	return c.watch(ctx, namespace, channel, false)
+}
+
+// Watch is WatchEvents that first reports every key already in the channel as
+// EventCreated, so a consumer can fill a cache from the stream alone and stay
+// consistent through later writes and prunes. Like WatchEvents it polls, to
+// keep the package free of dependencies.
+func Watch(ctx context.Context, namespace, channel string) (<-chan Event, error) {
+	return envClient().Watch(ctx, namespace, channel)
+}
+
+func (c *Client) Watch(ctx context.Context, namespace, channel string) (<-chan Event, error) {
+	return c.watch(ctx, namespace, channel, true)
+}
+
+func (c *Client) watch(ctx context.Context, namespace, channel string, prime bool) (<-chan Event, error) {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := c.readChannelEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]channelEntry, len(initial))
+	// expiresAt holds the expiry of reported envelopes, so one that expires
+	// while its file stays unchanged is still noticed.
+	expiresAt := make(map[string]time.Time)
+	if !prime {
+		for _, entry := range initial {
+			seen[entry.name] = entry
+		}
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(c.cfg.PollInterval)
+		defer ticker.Stop()
+
+		scan := func() bool {
+			current, err := c.readChannelEntries(dir)
+			if err != nil {
+				return true
+			}
+			present := make(map[string]struct{}, len(current))
+			for _, entry := range current {
+				present[entry.name] = struct{}{}
+				prev, known := seen[entry.name]
+				if known && prev.modTime.Equal(entry.modTime) && prev.size == entry.size {
+					if at, ok := expiresAt[entry.name]; !ok || c.now().Before(at) {
+						continue
+					}
+				}
+				env, err := c.readWatched(entry.path)
+				if err != nil {
+					// Retry on the next tick. A key already reported has
+					// expired or been rewritten unreadably, so consumers must
+					// drop the value they hold; it comes back as created
+					// once it reads cleanly.
+					delete(seen, entry.name)
+					delete(expiresAt, entry.name)
+					if known && !sendEvent(ctx, events, Event{Kind: EventRemoved, Key: entry.key, Path: entry.path}) {
+						return false
+					}
+					continue
+				}
+				seen[entry.name] = entry
+				if at, ok := env.ExpiresAt(); ok && c.cfg.HonorExpiry {
+					expiresAt[entry.name] = at
+				} else {
+					delete(expiresAt, entry.name)
+				}
+				kind := EventCreated
+				if known {
+					kind = EventUpdated
+				}
+				if !sendEvent(ctx, events, Event{Kind: kind, Key: entry.key, Path: entry.path, Envelope: &env}) {
+					return false
+				}
+			}
+			for name, entry := range seen {
+				if _, ok := present[name]; ok {
+					continue
+				}
+				delete(seen, name)
+				delete(expiresAt, name)
+				if !sendEvent(ctx, events, Event{Kind: EventRemoved, Key: entry.key, Path: entry.path}) {
+					return false
+				}
+			}
+			return true
+		}
+
+		if prime && !scan() {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !scan() {
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Tail replays the envelopes already in a channel in timestamp order, then
+// polls every PollInterval and delivers newly created files to handler, every
+// item of a bundle included. Each file name is delivered once; rewrites of a
+// delivered key are not replayed. Tail returns nil when ctx is cancelled and
+// stops with the handler's error if it returns one. Unreadable files are
+// retried on every poll but reported to the skip handler once until they
+// change.
+func Tail(ctx context.Context, namespace, channel string, handler func(Envelope) error) error {
+	return envClient().Tail(ctx, namespace, channel, handler)
+}
+
+func (c *Client) Tail(ctx context.Context, namespace, channel string, handler func(Envelope) error) error {
+	dir, err := c.ChannelDir(namespace, channel)
+	if err != nil {
+		return err
+	}
+
+	delivered := make(map[string]struct{})
+	reported := make(map[string]channelEntry)
+	deliver := func() error {
+		entries, err := c.readChannelEntries(dir)
+		if err != nil {
+			return err
+		}
+		present := make(map[string]struct{}, len(entries))
+		var fresh []keyedEnvelope
+		names := make(map[string]string)
+		for _, entry := range entries {
+			present[entry.name] = struct{}{}
+			if _, ok := delivered[entry.name]; ok {
+				continue
+			}
+			envs, err := c.readChannelFile(entry.path)
+			if err != nil {
+				prev, ok := reported[entry.name]
+				if !ok || !prev.modTime.Equal(entry.modTime) || prev.size != entry.size {
+					c.skip(entry.path, err)
+					reported[entry.name] = entry
+				}
+				continue
+			}
+			delete(reported, entry.name)
+			for idx, env := range envs {
+				if !c.expired(env) {
+					fresh = append(fresh, keyedEnvelope{key: entry.key, seq: idx, at: parseTimestamp(env.Timestamp), env: env})
+					names[entry.key] = entry.name
+				}
+			}
+		}
+		for name := range delivered {
+			if _, ok := present[name]; !ok {
+				delete(delivered, name)
+			}
+		}
+		for name := range reported {
+			if _, ok := present[name]; !ok {
+				delete(reported, name)
+			}
+		}
+
+		sortKeyed(fresh)
+		for _, item := range fresh {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := handler(item.env); err != nil {
+				return err
+			}
+			delivered[names[item.key]] = struct{}{}
+		}
+		return nil
+	}
+
+	if err := deliver(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(c.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := deliver(); err != nil {
+			return err
+		}
+	}
+}
+
+// readWatched reads the envelope a watch reports for a channel file: for a
+// bundle, the item ListChannel orders last. A file whose envelopes have all
+// expired fails with ErrExpired.
+func (c *Client) readWatched(sourcePath string) (Envelope, error) {
+	envs, err := c.readChannelFile(sourcePath)
+	if err != nil {
+		return Envelope{}, err
+	}
+	var items []keyedEnvelope
+	for idx, env := range envs {
+		if !c.expired(env) {
+			items = append(items, keyedEnvelope{seq: idx, at: parseTimestamp(env.Timestamp), env: env})
+		}
+	}
+	if len(items) == 0 {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrExpired, sourcePath)
+	}
+	sortKeyed(items)
+	return items[len(items)-1].env, nil
+}
+
+func sendEvent(ctx context.Context, events chan<- Event, ev Event) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,183 @@
+package interband
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatchEventsReportsLifecycle(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_POLL_INTERVAL_MS", "10")
+
+	if _, err := ChannelDir("custom", "events"); err != nil {
+		t.Fatalf("channel dir error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := WatchEvents(ctx, "custom", "events")
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	p, err := Path("custom", "events", "k")
+	if err != nil {
+		t.Fatalf("path error: %v", err)
+	}
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	ev := <-events
+	if ev.Kind != EventCreated || ev.Key != "k" || ev.Envelope == nil {
+		t.Fatalf("unexpected create event: %+v", ev)
+	}
+
+	if err := Write(p, "custom", "anything", "sess", map[string]any{"n": 22}); err != nil {
+		t.Fatalf("rewrite failed: %v", err)
+	}
+	ev = <-events
+	if ev.Kind != EventUpdated || ev.Envelope == nil {
+		t.Fatalf("unexpected update event: %+v", ev)
+	}
+
+	if err := os.Remove(p); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	ev = <-events
+	if ev.Kind != EventRemoved || ev.Key != "k" || ev.Envelope != nil {
+		t.Fatalf("unexpected remove event: %+v", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchPrimesAndReportsPrunedKeys(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_POLL_INTERVAL_MS", "10")
+	t.Setenv("INTERBAND_PRUNE_INTERVAL_SECS", "0")
+	t.Setenv("INTERBAND_MAX_FILES_CUSTOM_EVENTS", "1")
+
+	old, _ := Path("custom", "events", "old")
+	if err := Write(old, "custom", "anything", "sess", map[string]any{"n": 1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := Watch(ctx, "custom", "events")
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	ev := <-events
+	if ev.Kind != EventCreated || ev.Key != "old" || ev.Envelope == nil {
+		t.Fatalf("expected existing key to be primed, got %+v", ev)
+	}
+
+	fresh, _ := Path("custom", "events", "fresh")
+	if err := Write(fresh, "custom", "anything", "sess", map[string]any{"n": 2}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := PruneChannel("custom", "events"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	got := map[string]EventKind{}
+	for len(got) < 2 {
+		ev := <-events
+		got[ev.Key] = ev.Kind
+	}
+	if got["fresh"] != EventCreated || got["old"] != EventRemoved {
+		t.Fatalf("unexpected events: %v", got)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestTailReplaysThenFollows(t *testing.T) {
+	t.Setenv("INTERBAND_ROOT", t.TempDir())
+	t.Setenv("INTERBAND_POLL_INTERVAL_MS", "10")
+
+	write := func(key string, n int) {
+		p, err := Path("custom", "events", key)
+		if err != nil {
+			t.Fatalf("path error: %v", err)
+		}
+		if err := Write(p, "custom", "anything", "sess", map[string]any{"n": n}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	write("first", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var seen []float64
+	stop := errors.New("stop")
+	err := Tail(ctx, "custom", "events", func(env Envelope) error {
+		seen = append(seen, env.Payload["n"].(float64))
+		if len(seen) == 1 {
+			write("second", 2)
+			return nil
+		}
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected handler error to propagate, got %v", err)
+	}
+	if len(seen) != 2 || seen[0] != 1 || seen[1] != 2 {
+		t.Fatalf("unexpected delivery order: %v", seen)
+	}
+
+	cancel()
+	if err := Tail(ctx, "custom", "events", func(Envelope) error { return nil }); err != nil {
+		t.Fatalf("expected clean return on cancelled context, got %v", err)
+	}
+}
+
+func TestWatchEventsReportsExpiredKeysAsRemoved(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	cfg.PruneInterval = 0
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.HonorExpiry = true
+	cfg.Clock = clock
+	c := NewClient(cfg)
+
+	if _, err := c.ChannelDir("custom", "leases"); err != nil {
+		t.Fatalf("channel dir error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := c.WatchEvents(ctx, "custom", "leases")
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	writeExpiring(t, c, "lease", start.Add(time.Minute).Format(time.RFC3339))
+	ev := <-events
+	if ev.Kind != EventCreated || ev.Key != "lease" {
+		t.Fatalf("unexpected create event: %+v", ev)
+	}
+
+	clock.Advance(2 * time.Minute)
+	ev = <-events
+	if ev.Kind != EventRemoved || ev.Key != "lease" || ev.Envelope != nil {
+		t.Fatalf("expected expired key to be removed, got %+v", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}
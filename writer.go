@@ -0,0 +1,116 @@
+package interband
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Writer writes messages into one channel for high-rate producers. It
+// resolves the channel directory once, creates directories only the first
+// time it writes into them, and reuses one encode buffer, while each message
+// is still written to its own temp file and renamed into place. A Writer is
+// safe for concurrent use; its writes are serialized.
+type Writer struct {
+	c         *Client
+	namespace string
+	channel   string
+	dir       string
+	err       error
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	enc   *json.Encoder
+	ready map[string]struct{} // directories known to exist
+}
+
+// NewWriter returns a Writer for namespace/channel. An invalid channel is
+// reported by every Write.
+func NewWriter(namespace, channel string) *Writer {
+	return envClient().NewWriter(namespace, channel)
+}
+
+func (c *Client) NewWriter(namespace, channel string) *Writer {
+	w := &Writer{c: c, namespace: namespace, channel: channel, ready: make(map[string]struct{})}
+	if strings.TrimSpace(namespace) == "" || strings.TrimSpace(channel) == "" {
+		w.err = errors.New("namespace and channel are required")
+	} else {
+		w.dir, w.err = c.ChannelDir(namespace, channel)
+	}
+	w.enc = json.NewEncoder(&w.buf)
+	w.enc.SetEscapeHTML(false)
+	return w
+}
+
+// Write writes a message for key as WriteKey does.
+func (w *Writer) Write(key, typ, sessionID string, payload map[string]any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.c.writable(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(key) == "" {
+		return errors.New("key is required")
+	}
+	p, err := w.c.keyPath(w.dir, key)
+	if err != nil {
+		return err
+	}
+	env, err := w.c.newEnvelope(p, w.namespace, typ, sessionID, payload)
+	if err != nil {
+		return err
+	}
+	env.Key = key
+	if err := ValidateEnvelope(env); err != nil {
+		return w.c.observeValidation(err)
+	}
+	if err := w.c.checkPhaseTransition(p, env); err != nil {
+		return w.c.observeValidation(err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Reset()
+	if err := w.enc.Encode(env); err != nil {
+		return err
+	}
+	data := w.buf.Bytes()
+	if err := checkSize(p, int64(len(data)), w.c.cfg.MaxPayloadBytes); err != nil {
+		return err
+	}
+	if err := w.c.publishReady(w.ready, p, data); err != nil {
+		return err
+	}
+	w.c.observe(func(o Observer) { o.OnWrite(env.Namespace, env.Type, len(data)) })
+	return nil
+}
+
+// publishReady writes data to p, skipping directory creation on the default
+// backend once ready records the directory as existing.
+func (c *Client) publishReady(ready map[string]struct{}, p string, data []byte) error {
+	ctx := context.Background()
+	ob, ok := c.backend().(OSBackend)
+	if !ok {
+		return c.backend().WriteAtomic(ctx, p, data)
+	}
+	dir := filepath.Dir(p)
+	if _, ok := ready[dir]; ok {
+		err := publishInDir(ctx, p, data, ob.FileMode, false)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		// The directory was removed underneath us; recreate it.
+		delete(ready, dir)
+	}
+	if err := publishFile(ctx, p, data, ob.FileMode, ob.DirMode, false); err != nil {
+		return err
+	}
+	ready[dir] = struct{}{}
+	return nil
+}
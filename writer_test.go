@@ -0,0 +1,79 @@
+package interband
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Root = t.TempDir()
+	c := NewClient(cfg)
+	w := c.NewWriter("custom", "ingest")
+
+	for idx := 0; idx < 3; idx++ {
+		if err := w.Write("k"+strconv.Itoa(idx), "anything", "s", map[string]any{"n": idx}); err != nil {
+			t.Fatalf("write %d failed: %v", idx, err)
+		}
+	}
+	envs, skipped, err := c.ListChannel("custom", "ingest")
+	if err != nil || len(skipped) != 0 || len(envs) != 3 {
+		t.Fatalf("list: %d envelopes, %v, %v", len(envs), skipped, err)
+	}
+	if envs[0].Key == "" {
+		t.Fatal("writer should record the key")
+	}
+
+	// A channel directory removed between writes is recreated.
+	dir, _ := c.ChannelDir("custom", "ingest")
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write("again", "anything", "s", map[string]any{}); err != nil {
+		t.Fatalf("write after removal failed: %v", err)
+	}
+	if ok, _ := c.Exists("custom", "ingest", "again"); !ok {
+		t.Fatal("message missing after directory was recreated")
+	}
+
+	if err := w.Write("bad", "", "s", map[string]any{}); err == nil {
+		t.Fatal("expected a missing type to fail")
+	}
+	if err := c.NewWriter("custom", "..").Write("k", "anything", "s", map[string]any{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for a bad channel, got %v", err)
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	cfg := DefaultConfig()
+	cfg.Root = b.TempDir()
+	c := NewClient(cfg)
+	payload := map[string]any{"name": "bench", "n": 1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, err := c.Path("custom", "bench", strconv.Itoa(i%64))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := c.Write(p, "custom", "anything", "s", payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriterWrite(b *testing.B) {
+	cfg := DefaultConfig()
+	cfg.Root = b.TempDir()
+	w := NewClient(cfg).NewWriter("custom", "bench")
+	payload := map[string]any{"name": "bench", "n": 1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := w.Write(strconv.Itoa(i%64), "anything", "s", payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}